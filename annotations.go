@@ -0,0 +1,317 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v53/github"
+)
+
+// maxAnnotationsPerRequest is the number of annotations the Checks API accepts in a single request.
+const maxAnnotationsPerRequest = 50
+
+const annotationsFormatAuto = "auto"
+const annotationsFormatGoVet = "govet"
+const annotationsFormatGolangciLint = "golangci-lint"
+const annotationsFormatStaticcheck = "staticcheck"
+const annotationsFormatMatcher = "matcher"
+
+const annotationLevelNotice = "notice"
+const annotationLevelWarning = "warning"
+const annotationLevelFailure = "failure"
+
+// goVetLine matches the `file.go:line:column: message` lines printed by go vet and the go compiler.
+var goVetLine = regexp.MustCompile(`^(?:vet: )?([^\s:#][^:]*\.go):(\d+)(?::(\d+))?: (.+)$`)
+
+// golangciLintReport is the output of `golangci-lint run --out-format json`.
+type golangciLintReport struct {
+	Issues []struct {
+		FromLinter string `json:"FromLinter"`
+		Text       string `json:"Text"`
+		Severity   string `json:"Severity"`
+		Pos        struct {
+			Filename string `json:"Filename"`
+			Line     int    `json:"Line"`
+			Column   int    `json:"Column"`
+		} `json:"Pos"`
+	} `json:"Issues"`
+}
+
+// staticcheckPosition is a position in the output of `staticcheck -f json`.
+type staticcheckPosition struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// staticcheckProblem is a single line of the output of `staticcheck -f json`.
+type staticcheckProblem struct {
+	Code     string              `json:"code"`
+	Severity string              `json:"severity"`
+	Location staticcheckPosition `json:"location"`
+	End      staticcheckPosition `json:"end"`
+	Message  string              `json:"message"`
+}
+
+// problemMatcherFile is a GitHub problem matcher file, the same format used by `::add-matcher::`.
+type problemMatcherFile struct {
+	ProblemMatcher []struct {
+		Owner    string `json:"owner"`
+		Severity string `json:"severity"`
+		Pattern  []struct {
+			Regexp   string `json:"regexp"`
+			File     int    `json:"file"`
+			Line     int    `json:"line"`
+			Column   int    `json:"column"`
+			Severity int    `json:"severity"`
+			Code     int    `json:"code"`
+			Message  int    `json:"message"`
+		} `json:"pattern"`
+	} `json:"problemMatcher"`
+}
+
+// getAnnotations reads the annotations file and parses it in the given format. The problem matcher file is only used
+// by the matcher format.
+func getAnnotations(annotationsFile, format, matcherFile string) ([]*github.CheckRunAnnotation, error) {
+	data, err := os.ReadFile(annotationsFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read annotations_file: %w", err)
+	}
+
+	if format == "" || format == annotationsFormatAuto {
+		format = detectAnnotationsFormat(data, matcherFile)
+	}
+
+	switch format {
+	case annotationsFormatGoVet:
+		return parseGoVet(data), nil
+	case annotationsFormatGolangciLint:
+		return parseGolangciLint(data)
+	case annotationsFormatStaticcheck:
+		return parseStaticcheck(data)
+	case annotationsFormatMatcher:
+		if matcherFile == "" {
+			return nil, errors.New("annotations_matcher is required for the matcher annotations format")
+		}
+		matcher, err := os.ReadFile(matcherFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read annotations_matcher: %w", err)
+		}
+		return parseProblemMatcher(data, matcher)
+	default:
+		return nil, fmt.Errorf("annotations format not supported: %s", format)
+	}
+}
+
+// detectAnnotationsFormat guesses the format of the annotations file from its content.
+func detectAnnotationsFormat(data []byte, matcherFile string) string {
+	if matcherFile != "" {
+		return annotationsFormatMatcher
+	}
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(trimmed, []byte("{")) {
+		return annotationsFormatGoVet
+	}
+	if bytes.Contains(trimmed, []byte(`"Issues"`)) {
+		return annotationsFormatGolangciLint
+	}
+	return annotationsFormatStaticcheck
+}
+
+// parseGoVet parses go vet and go compiler output. Lines that are not diagnostics, like package headers, are skipped.
+func parseGoVet(data []byte) []*github.CheckRunAnnotation {
+	var annotations []*github.CheckRunAnnotation
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		m := goVetLine.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if m == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+		annotations = append(annotations, newAnnotation(m[1], line, line, column, annotationLevelFailure, m[4], ""))
+	}
+	return annotations
+}
+
+// parseGolangciLint parses the JSON report of golangci-lint.
+func parseGolangciLint(data []byte) ([]*github.CheckRunAnnotation, error) {
+	var report golangciLintReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("unable to parse golangci-lint output: %w", err)
+	}
+
+	annotations := make([]*github.CheckRunAnnotation, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		annotations = append(annotations, newAnnotation(issue.Pos.Filename, issue.Pos.Line, issue.Pos.Line, issue.Pos.Column,
+			annotationLevel(issue.Severity), issue.Text, issue.FromLinter))
+	}
+	return annotations, nil
+}
+
+// parseStaticcheck parses the JSON lines output of staticcheck. Ignored problems are skipped.
+func parseStaticcheck(data []byte) ([]*github.CheckRunAnnotation, error) {
+	var annotations []*github.CheckRunAnnotation
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for decoder.More() {
+		var problem staticcheckProblem
+		if err := decoder.Decode(&problem); err != nil {
+			return nil, fmt.Errorf("unable to parse staticcheck output: %w", err)
+		}
+		if problem.Severity == "ignored" {
+			continue
+		}
+		endLine := problem.End.Line
+		if endLine < problem.Location.Line {
+			endLine = problem.Location.Line
+		}
+		annotations = append(annotations, newAnnotation(problem.Location.File, problem.Location.Line, endLine, problem.Location.Column,
+			annotationLevel(problem.Severity), problem.Message, problem.Code))
+	}
+	return annotations, nil
+}
+
+// parseProblemMatcher parses output with the regular expressions of a GitHub problem matcher. Only single line
+// patterns are supported.
+func parseProblemMatcher(data, matcherData []byte) ([]*github.CheckRunAnnotation, error) {
+	var matchers problemMatcherFile
+	if err := json.Unmarshal(matcherData, &matchers); err != nil {
+		return nil, fmt.Errorf("unable to parse annotations_matcher: %w", err)
+	}
+
+	var annotations []*github.CheckRunAnnotation
+	for _, matcher := range matchers.ProblemMatcher {
+		if len(matcher.Pattern) != 1 {
+			return nil, fmt.Errorf("problem matcher %s: only single line patterns are supported", matcher.Owner)
+		}
+		pattern := matcher.Pattern[0]
+		re, err := regexp.Compile(pattern.Regexp)
+		if err != nil {
+			return nil, fmt.Errorf("problem matcher %s: %w", matcher.Owner, err)
+		}
+		// Annotations need a path, GitHub rejects the whole batch when one of them has none
+		if pattern.File <= 0 || pattern.File > re.NumSubexp() {
+			return nil, fmt.Errorf("problem matcher %s: file must be a group of the regexp", matcher.Owner)
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			m := re.FindStringSubmatch(scanner.Text())
+			if m == nil {
+				continue
+			}
+			group := func(i int) string {
+				if i <= 0 || i >= len(m) {
+					return ""
+				}
+				return m[i]
+			}
+			file := group(pattern.File)
+			if file == "" {
+				continue
+			}
+			line, _ := strconv.Atoi(group(pattern.Line))
+			column, _ := strconv.Atoi(group(pattern.Column))
+			severity := group(pattern.Severity)
+			if severity == "" {
+				severity = matcher.Severity
+			}
+			annotations = append(annotations, newAnnotation(file, line, line, column,
+				annotationLevel(severity), group(pattern.Message), group(pattern.Code)))
+		}
+	}
+	return annotations, nil
+}
+
+// newAnnotation creates a check run annotation. The path is made relative to the workspace because GitHub expects
+// paths relative to the root of the repository.
+func newAnnotation(path string, startLine, endLine, column int, level, message, title string) *github.CheckRunAnnotation {
+	if startLine < 1 {
+		startLine = 1
+	}
+	if endLine < startLine {
+		endLine = startLine
+	}
+
+	annotation := &github.CheckRunAnnotation{
+		Path:            github.String(relativeAnnotationPath(path)),
+		StartLine:       github.Int(startLine),
+		EndLine:         github.Int(endLine),
+		AnnotationLevel: github.String(level),
+		Message:         github.String(message),
+	}
+	// Columns can only be set when the annotation is on a single line.
+	if column > 0 && startLine == endLine {
+		annotation.StartColumn = github.Int(column)
+		annotation.EndColumn = github.Int(column)
+	}
+	if title != "" {
+		annotation.Title = github.String(title)
+	}
+	return annotation
+}
+
+// relativeAnnotationPath makes a path relative to the workspace when possible. Inside the container the workspace is
+// GITHUB_WORKSPACE, but tools that ran on the runner print paths in the workspace of the runner, which is the directory
+// named after the repository in RUNNER_WORKSPACE.
+func relativeAnnotationPath(path string) string {
+	if filepath.IsAbs(path) {
+		for _, workspace := range annotationWorkspaces() {
+			if rel, err := filepath.Rel(workspace, path); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+				break
+			}
+		}
+	}
+	return filepath.ToSlash(strings.TrimPrefix(path, "./"))
+}
+
+// annotationWorkspaces returns the workspaces paths can be relative to, in the container and on the runner.
+func annotationWorkspaces() []string {
+	var workspaces []string
+	if workspace := os.Getenv("GITHUB_WORKSPACE"); workspace != "" {
+		workspaces = append(workspaces, workspace)
+	}
+	runnerWorkspace := os.Getenv("RUNNER_WORKSPACE")
+	_, repository, ok := strings.Cut(os.Getenv("GITHUB_REPOSITORY"), "/")
+	if runnerWorkspace != "" && ok {
+		workspaces = append(workspaces, filepath.Join(runnerWorkspace, repository))
+	}
+	return workspaces
+}
+
+// annotationLevel converts a linter severity into a check run annotation level.
+func annotationLevel(severity string) string {
+	switch strings.ToLower(severity) {
+	case "warning", "warn":
+		return annotationLevelWarning
+	case "info", "notice", "note":
+		return annotationLevelNotice
+	default:
+		return annotationLevelFailure
+	}
+}
+
+// batchAnnotations splits annotations into batches the Checks API accepts in a single request.
+func batchAnnotations(annotations []*github.CheckRunAnnotation) [][]*github.CheckRunAnnotation {
+	var batches [][]*github.CheckRunAnnotation
+	for len(annotations) > maxAnnotationsPerRequest {
+		batches = append(batches, annotations[:maxAnnotationsPerRequest])
+		annotations = annotations[maxAnnotationsPerRequest:]
+	}
+	if len(annotations) > 0 {
+		batches = append(batches, annotations)
+	}
+	return batches
+}
@@ -0,0 +1,170 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAnnotations(t *testing.T) {
+	matcher := `{
+  "problemMatcher": [
+    {
+      "owner": "eslint",
+      "severity": "warning",
+      "pattern": [
+        {
+          "regexp": "^(.+):(\\d+):(\\d+): (error|warning) (.+) \\[(.+)\\]$",
+          "file": 1, "line": 2, "column": 3, "severity": 4, "message": 5, "code": 6
+        }
+      ]
+    }
+  ]
+}`
+
+	cases := []struct {
+		name        string
+		content     string
+		format      string
+		matcher     string
+		expected    []*github.CheckRunAnnotation
+		expectError string
+	}{
+		{
+			name:    "go_vet",
+			content: "# github.com/foo/bar\nvet: ./main.go:12:3: unreachable code\n./foo.go:4: missing return\n",
+			expected: []*github.CheckRunAnnotation{
+				newAnnotation("main.go", 12, 12, 3, annotationLevelFailure, "unreachable code", ""),
+				newAnnotation("foo.go", 4, 4, 0, annotationLevelFailure, "missing return", ""),
+			},
+		},
+		{
+			name:    "golangci_lint",
+			content: `{"Issues":[{"FromLinter":"godot","Text":"Comment should end in a period","Severity":"warning","Pos":{"Filename":"main.go","Line":20,"Column":1}}],"Report":{}}`,
+			expected: []*github.CheckRunAnnotation{
+				newAnnotation("main.go", 20, 20, 1, annotationLevelWarning, "Comment should end in a period", "godot"),
+			},
+		},
+		{
+			name: "staticcheck",
+			content: `{"code":"SA4006","severity":"error","location":{"file":"main.go","line":3,"column":2},"end":{"file":"main.go","line":5,"column":1},"message":"value never used"}
+{"code":"ST1000","severity":"ignored","location":{"file":"main.go","line":1,"column":1},"end":{"file":"main.go","line":1,"column":1},"message":"ignored"}`,
+			expected: []*github.CheckRunAnnotation{
+				newAnnotation("main.go", 3, 5, 2, annotationLevelFailure, "value never used", "SA4006"),
+			},
+		},
+		{
+			name:    "matcher",
+			content: "src/app.js:7:9: error Unexpected var [no-var]\nnot a problem\n",
+			matcher: matcher,
+			expected: []*github.CheckRunAnnotation{
+				newAnnotation("src/app.js", 7, 7, 9, annotationLevelFailure, "Unexpected var", "no-var"),
+			},
+		},
+		{
+			name:    "matcher_skips_matches_without_file",
+			content: "src/app.js:7:9: error Unexpected var [no-var]\n:1:1: error No file [no-file]\n",
+			matcher: strings.Replace(matcher, "(.+):(\\\\d+)", "(.*):(\\\\d+)", 1),
+			expected: []*github.CheckRunAnnotation{
+				newAnnotation("src/app.js", 7, 7, 9, annotationLevelFailure, "Unexpected var", "no-var"),
+			},
+		},
+		{
+			name:        "error_matcher_without_file",
+			content:     "src/app.js:7:9: error Unexpected var [no-var]\n",
+			matcher:     strings.Replace(matcher, `"file": 1, `, "", 1),
+			expectError: "problem matcher eslint: file must be a group of the regexp",
+		},
+		{
+			name:        "error_matcher_format_without_matcher",
+			content:     "foo",
+			format:      annotationsFormatMatcher,
+			expectError: "annotations_matcher is required",
+		},
+		{
+			name:        "error_unknown_format",
+			content:     "foo",
+			format:      "foo",
+			expectError: "annotations format not supported",
+		},
+		{
+			name:        "error_invalid_json",
+			content:     `{"Issues":`,
+			expectError: "unable to parse golangci-lint output",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			annotationsFile := filepath.Join(dir, "annotations")
+			require.NoError(t, os.WriteFile(annotationsFile, []byte(c.content), 0o600))
+			matcherFile := ""
+			if c.matcher != "" {
+				matcherFile = filepath.Join(dir, "matcher.json")
+				require.NoError(t, os.WriteFile(matcherFile, []byte(c.matcher), 0o600))
+			}
+
+			got, err := getAnnotations(annotationsFile, c.format, matcherFile)
+			if c.expectError != "" {
+				require.ErrorContains(t, err, c.expectError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, got)
+		})
+	}
+}
+
+func TestRelativeAnnotationPath(t *testing.T) {
+	t.Setenv("GITHUB_WORKSPACE", "/github/workspace")
+	require.Equal(t, "pkg/foo.go", relativeAnnotationPath("/github/workspace/pkg/foo.go"))
+	require.Equal(t, "pkg/foo.go", relativeAnnotationPath("./pkg/foo.go"))
+	require.Equal(t, "/other/foo.go", relativeAnnotationPath("/other/foo.go"))
+
+	// Tools that ran on the runner print the paths of the runner
+	t.Setenv("RUNNER_WORKSPACE", "/home/runner/work/some-repo")
+	t.Setenv("GITHUB_REPOSITORY", "some-owner/some-repo")
+	require.Equal(t, "pkg/foo.go", relativeAnnotationPath("/home/runner/work/some-repo/some-repo/pkg/foo.go"))
+	require.Equal(t, "/home/runner/work/some-repo/other/foo.go", relativeAnnotationPath("/home/runner/work/some-repo/other/foo.go"))
+}
+
+func TestBatchAnnotations(t *testing.T) {
+	cases := []struct {
+		name     string
+		count    int
+		expected []int
+	}{
+		{
+			name:     "no_annotations",
+			count:    0,
+			expected: nil,
+		},
+		{
+			name:     "single_batch",
+			count:    50,
+			expected: []int{50},
+		},
+		{
+			name:     "multiple_batches",
+			count:    101,
+			expected: []int{50, 50, 1},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			annotations := make([]*github.CheckRunAnnotation, c.count)
+			var got []int
+			for _, batch := range batchAnnotations(annotations) {
+				got = append(got, len(batch))
+			}
+			require.Equal(t, c.expected, got)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v53/github"
+	"golang.org/x/oauth2"
+)
+
+// appJWTLifetime is how long the JWT used to authenticate as the GitHub App is valid. GitHub allows at most 10 minutes.
+const appJWTLifetime = 9 * time.Minute
+
+// appJWTClockDrift is subtracted from the issued at time to allow for clock drift between the runner and GitHub.
+const appJWTClockDrift = time.Minute
+
+type ghAppsClient interface {
+	FindRepositoryInstallation(context.Context, string, string) (*github.Installation, *github.Response, error)
+	CreateInstallationToken(context.Context, int64, *github.InstallationTokenOptions) (*github.InstallationToken, *github.Response, error)
+}
+
+// appTokenSource mints installation access tokens for a GitHub App. It is wrapped in an oauth2.ReuseTokenSource so a
+// new token is only minted when the previous one expires.
+type appTokenSource struct {
+	ctx            context.Context
+	appID          int64
+	key            *rsa.PrivateKey
+	installationID int64
+	owner          string
+	repository     string
+	// newAppsClient creates a client that authenticates as the GitHub App with the JWT.
+	newAppsClient func(jwt string) ghAppsClient
+}
+
+// newTokenSource returns the token source for the inputs. A GitHub App is used when an app ID is set, otherwise the
+// token is used as is.
+func newTokenSource(ctx context.Context, in input, apiURL string) (oauth2.TokenSource, error) {
+	if in.appID == 0 {
+		return oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: in.token},
+		), nil
+	}
+
+	key, err := parsePrivateKey(in.privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return oauth2.ReuseTokenSource(nil, &appTokenSource{
+		ctx:            ctx,
+		appID:          in.appID,
+		key:            key,
+		installationID: in.installationID,
+		owner:          in.owner,
+		repository:     in.repository,
+		newAppsClient: func(jwt string) ghAppsClient {
+			tc := oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: jwt}))
+			// The API URL has already been validated when the client was created.
+			client, _ := newGitHubClient(tc, apiURL)
+			return client.Apps
+		},
+	}), nil
+}
+
+// Token exchanges a GitHub App JWT for an installation access token. The installation for the owner and repository is
+// looked up when no installation ID is set.
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := newAppJWT(s.appID, s.key, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	apps := s.newAppsClient(jwt)
+
+	if s.installationID == 0 {
+		installation, _, err := apps.FindRepositoryInstallation(s.ctx, s.owner, s.repository)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find the GitHub App installation for %s/%s: %w", s.owner, s.repository, err)
+		}
+		s.installationID = installation.GetID()
+	}
+
+	token, _, err := apps.CreateInstallationToken(s.ctx, s.installationID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create an installation token for installation %d: %w", s.installationID, err)
+	}
+	if token.GetToken() == "" {
+		return nil, errors.New("installation token returned is empty")
+	}
+
+	action.AddMask(token.GetToken())
+	action.Infof("Using installation token for GitHub App %d, expires at %s", s.appID, token.GetExpiresAt().Format(time.RFC3339))
+	return &oauth2.Token{
+		AccessToken: token.GetToken(),
+		Expiry:      token.GetExpiresAt().Time,
+	}, nil
+}
+
+// newAppJWT creates a JWT signed with the private key of the GitHub App.
+func newAppJWT(appID int64, key *rsa.PrivateKey, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-appJWTClockDrift).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("unable to sign the GitHub App JWT: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parsePrivateKey parses the PEM encoded private key of a GitHub App. Keys stored with escaped newlines are accepted.
+func parsePrivateKey(privateKey string) (*rsa.PrivateKey, error) {
+	if !strings.Contains(privateKey, "\n") {
+		privateKey = strings.ReplaceAll(privateKey, `\n`, "\n")
+	}
+
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return nil, errors.New("private_key is not a PEM encoded key")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse private_key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private_key is not an RSA key")
+	}
+	return key, nil
+}
@@ -0,0 +1,181 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAppJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+
+	jwt, err := newAppJWT(24601, key, now)
+	require.NoError(t, err)
+
+	parts := strings.Split(jwt, ".")
+	require.Len(t, parts, 3)
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	var claims map[string]interface{}
+	require.NoError(t, json.Unmarshal(claimsJSON, &claims))
+	require.Equal(t, "24601", claims["iss"])
+	require.Equal(t, float64(now.Add(-appJWTClockDrift).Unix()), claims["iat"])
+	require.Equal(t, float64(now.Add(appJWTLifetime).Unix()), claims["exp"])
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	require.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pkcs1 := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	pkcs8Bytes, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	pkcs8 := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8Bytes}))
+
+	cases := []struct {
+		name        string
+		privateKey  string
+		expectError string
+	}{
+		{
+			name:       "pkcs1",
+			privateKey: pkcs1,
+		},
+		{
+			name:       "pkcs8",
+			privateKey: pkcs8,
+		},
+		{
+			name:       "escaped_newlines",
+			privateKey: strings.ReplaceAll(pkcs1, "\n", `\n`),
+		},
+		{
+			name:        "error_not_pem",
+			privateKey:  "foo",
+			expectError: "not a PEM encoded key",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := parsePrivateKey(c.privateKey)
+			if c.expectError != "" {
+				require.ErrorContains(t, err, c.expectError)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, key.Equal(got))
+		})
+	}
+}
+
+func TestAppTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	cases := []struct {
+		name                   string
+		installationID         int64
+		apps                   *mockghAppsClient
+		expectedInstallationID int64
+		expectLookup           bool
+		expectError            string
+	}{
+		{
+			name:                   "installation_looked_up",
+			apps:                   &mockghAppsClient{installationID: 42, token: "some-token", expiry: expiry},
+			expectedInstallationID: 42,
+			expectLookup:           true,
+		},
+		{
+			name:                   "installation_id_set",
+			installationID:         7,
+			apps:                   &mockghAppsClient{token: "some-token", expiry: expiry},
+			expectedInstallationID: 7,
+		},
+		{
+			name:         "error_installation_not_found",
+			apps:         &mockghAppsClient{returnError: true},
+			expectLookup: true,
+			expectError:  "unable to find the GitHub App installation for some-owner/some-repo",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var jwt string
+			ts := &appTokenSource{
+				ctx:            context.Background(),
+				appID:          24601,
+				key:            key,
+				installationID: c.installationID,
+				owner:          "some-owner",
+				repository:     "some-repo",
+				newAppsClient: func(j string) ghAppsClient {
+					jwt = j
+					return c.apps
+				},
+			}
+
+			token, err := ts.Token()
+			require.NotEmpty(t, jwt)
+			require.Equal(t, c.expectLookup, c.apps.lookedUp)
+			if c.expectError != "" {
+				require.ErrorContains(t, err, c.expectError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "some-token", token.AccessToken)
+			require.Equal(t, expiry, token.Expiry)
+			require.Equal(t, c.expectedInstallationID, c.apps.tokenInstallationID)
+		})
+	}
+}
+
+type mockghAppsClient struct {
+	installationID int64
+	token          string
+	expiry         time.Time
+	returnError    bool
+
+	lookedUp            bool
+	tokenInstallationID int64
+}
+
+func (m *mockghAppsClient) FindRepositoryInstallation(_ context.Context, _, _ string) (*github.Installation, *github.Response, error) {
+	m.lookedUp = true
+	if m.returnError {
+		return nil, nil, errors.New("some-error")
+	}
+	return &github.Installation{ID: &m.installationID}, nil, nil
+}
+
+func (m *mockghAppsClient) CreateInstallationToken(_ context.Context, id int64, _ *github.InstallationTokenOptions) (*github.InstallationToken, *github.Response, error) {
+	m.tokenInstallationID = id
+	if m.returnError {
+		return nil, nil, errors.New("some-error")
+	}
+	return &github.InstallationToken{Token: &m.token, ExpiresAt: &github.Timestamp{Time: m.expiry}}, nil, nil
+}
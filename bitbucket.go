@@ -0,0 +1,180 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v53/github"
+)
+
+const defaultBitbucketAPIURL = "https://api.bitbucket.org"
+
+// permissionBitbucket is the scope a Bitbucket Cloud token needs to post build statuses.
+const permissionBitbucket = "repository:write"
+
+// permissionBitbucketDataCenter is the permission a Bitbucket Data Center token needs to post build statuses.
+const permissionBitbucketDataCenter = "REPO_READ"
+
+// bitbucketStates maps states to the states of Bitbucket build statuses. Cancelled and skipped jobs are STOPPED
+// rather than failed. A state mapped by state_map is converted from the state it maps to.
+var bitbucketStates = map[string]string{
+	"pending":         "INPROGRESS",
+	"queued":          "INPROGRESS",
+	"waiting":         "INPROGRESS",
+	"requested":       "INPROGRESS",
+	"in_progress":     "INPROGRESS",
+	"inprogress":      "INPROGRESS",
+	"running":         "INPROGRESS",
+	"success":         "SUCCESSFUL",
+	"successful":      "SUCCESSFUL",
+	"neutral":         "SUCCESSFUL",
+	"failure":         "FAILED",
+	"failed":          "FAILED",
+	"error":           "FAILED",
+	"timed_out":       "FAILED",
+	"action_required": "FAILED",
+	"startup_failure": "FAILED",
+	"stale":           "FAILED",
+	"stopped":         "STOPPED",
+	"cancel":          "STOPPED",
+	"cancelled":       "STOPPED",
+	"canceled":        "STOPPED",
+	"skipped":         "STOPPED",
+}
+
+// bitbucketClient posts build statuses to Bitbucket Cloud's /2.0/repositories/{workspace}/{repo}/commit/{sha}/statuses/build
+// endpoint or to Bitbucket Data Center's /rest/build-status/1.0/commits/{sha} endpoint.
+type bitbucketClient struct {
+	client     *http.Client
+	apiURL     string
+	dataCenter bool
+	// defaultURL links the statuses that have no details URL, which Bitbucket requires, to the workflow run.
+	defaultURL string
+}
+
+// bitbucketStatus is a Bitbucket build status, both the request and the response.
+type bitbucketStatus struct {
+	Key         string     `json:"key"`
+	Name        string     `json:"name,omitempty"`
+	URL         string     `json:"url"`
+	State       string     `json:"state"`
+	Description string     `json:"description,omitempty"`
+	CreatedOn   *time.Time `json:"created_on,omitempty"`
+}
+
+// newBitbucketClient creates a client for the Bitbucket API. With a username the token is an app password sent with
+// basic auth, otherwise it is an access token sent as a bearer token.
+func newBitbucketClient(in input) *bitbucketClient {
+	auth := "Bearer " + in.token
+	if in.username != "" {
+		auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(in.username+":"+in.token))
+	}
+	apiURL := strings.TrimSuffix(in.apiURL, "/")
+	if apiURL == "" {
+		apiURL = defaultBitbucketAPIURL
+	}
+	return &bitbucketClient{
+		client:     &http.Client{Transport: &authTransport{base: http.DefaultTransport, header: "Authorization", value: auth}},
+		apiURL:     apiURL,
+		dataCenter: in.provider == providerBitbucketDataCenter,
+		defaultURL: githubRunURL(),
+	}
+}
+
+// serverURL returns the web URL of Bitbucket. Bitbucket Cloud serves the API from an api. subdomain while Data Center
+// serves it from the same URL as the web UI.
+func (c *bitbucketClient) serverURL() string {
+	if c.dataCenter {
+		return c.apiURL
+	}
+	return serverURLFromAPIURL(c.apiURL)
+}
+
+func (c *bitbucketClient) CreateStatus(ctx context.Context, owner, repo, ref string, status *github.RepoStatus) (*github.RepoStatus, *github.Response, error) {
+	r := c.statusRequest(owner, repo, ref, status)
+	// Data Center answers with no content, so the status that was sent is returned instead
+	created := r.Body.(bitbucketStatus)
+	resp, err := doJSON(ctx, c.client, c.apiURL, r, &created)
+	if err != nil {
+		return nil, resp, err
+	}
+	return created.repoStatus(), resp, nil
+}
+
+// statusRequest returns the request that creates the status. The context is both the key and the name of the status.
+func (c *bitbucketClient) statusRequest(owner, repo, ref string, status *github.RepoStatus) dryRunRequest {
+	path := fmt.Sprintf("2.0/repositories/%s/%s/commit/%s/statuses/build", url.PathEscape(owner), url.PathEscape(repo), url.PathEscape(ref))
+	if c.dataCenter {
+		path = fmt.Sprintf("rest/build-status/1.0/commits/%s", url.PathEscape(ref))
+	}
+	detailsURL := status.GetTargetURL()
+	if detailsURL == "" {
+		detailsURL = c.defaultURL
+	}
+	return dryRunRequest{
+		Method:     http.MethodPost,
+		Path:       path,
+		Owner:      owner,
+		Repository: repo,
+		SHA:        ref,
+		Body: bitbucketStatus{
+			Key:         status.GetContext(),
+			Name:        status.GetContext(),
+			URL:         detailsURL,
+			State:       status.GetState(),
+			Description: status.GetDescription(),
+		},
+	}
+}
+
+// repoStatus converts the Bitbucket status to a GitHub one. Build statuses are identified by their key, so there is
+// no ID.
+func (s bitbucketStatus) repoStatus() *github.RepoStatus {
+	status := &github.RepoStatus{
+		ID:          github.Int64(0),
+		State:       github.String(s.State),
+		Context:     github.String(s.Key),
+		Description: github.String(s.Description),
+		TargetURL:   github.String(s.URL),
+	}
+	if s.CreatedOn != nil {
+		status.CreatedAt = &github.Timestamp{Time: *s.CreatedOn}
+	}
+	return status
+}
+
+// convertActionStateToBitbucketState validates that the state is a correct value and converts it to a Bitbucket build
+// status state, ignoring case. The mappings from state_map come first. The build status API of Data Center has no
+// STOPPED, so stopped builds are FAILED there.
+func convertActionStateToBitbucketState(actionState string, stateMap map[string]string, dataCenter bool) (string, error) {
+	key := strings.ToLower(strings.TrimSpace(actionState))
+	if state, ok := stateMap[key]; ok {
+		key = state
+	}
+	state, ok := bitbucketStates[key]
+	if !ok {
+		return "", fmt.Errorf("state value not supported: %s", actionState)
+	}
+	if dataCenter && state == "STOPPED" {
+		return "FAILED", nil
+	}
+	return state, nil
+}
+
+// githubRunURL returns the URL of the workflow run, or nothing outside of GitHub Actions.
+func githubRunURL() string {
+	serverURL, repository, runID := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID")
+	if serverURL == "" || repository == "" || runID == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/actions/runs/%s", strings.TrimSuffix(serverURL, "/"), repository, runID)
+}
@@ -0,0 +1,123 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertActionStateToBitbucketState(t *testing.T) {
+	cases := []struct {
+		name        string
+		actual      string
+		stateMap    map[string]string
+		dataCenter  bool
+		expected    string
+		expectError bool
+	}{
+		{name: "success", actual: "success", expected: "SUCCESSFUL"},
+		{name: "failure", actual: "failure", expected: "FAILED"},
+		{name: "error", actual: "error", expected: "FAILED"},
+		{name: "pending", actual: "pending", expected: "INPROGRESS"},
+		{name: "in_progress", actual: "in_progress", expected: "INPROGRESS"},
+		{name: "bitbucket_state", actual: "SUCCESSFUL", expected: "SUCCESSFUL"},
+		{name: "cancelled_is_stopped", actual: "cancelled", expected: "STOPPED"},
+		{name: "skipped_is_stopped", actual: "skipped", expected: "STOPPED"},
+		{name: "stopped_fails_on_data_center", actual: "stopped", dataCenter: true, expected: "FAILED"},
+		{name: "state_map_first", actual: "skipped", stateMap: map[string]string{"skipped": "success"}, expected: "SUCCESSFUL"},
+		{name: "state_map_to_bitbucket_state", actual: "timed_out", stateMap: map[string]string{"timed_out": "stopped"}, expected: "STOPPED"},
+		{name: "fail_with_invalid_state", actual: "foo", expectError: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			state, err := convertActionStateToBitbucketState(c.actual, c.stateMap, c.dataCenter)
+			assert.Equal(t, c.expected, state)
+			assert.Equal(t, c.expectError, err != nil)
+		})
+	}
+}
+
+func TestBitbucketCreateStatus(t *testing.T) {
+	status := &github.RepoStatus{
+		State:       github.String("INPROGRESS"),
+		Context:     github.String("ci"),
+		Description: github.String("Building"),
+		TargetURL:   github.String("https://some-url"),
+	}
+	cases := []struct {
+		name         string
+		inputs       input
+		statusCode   int
+		response     string
+		expectedPath string
+		expectedAuth string
+	}{
+		{
+			name:         "cloud_with_app_password",
+			inputs:       input{provider: providerBitbucket, username: "some-user", token: "some-password"},
+			statusCode:   http.StatusCreated,
+			response:     `{"key":"ci","name":"ci","url":"https://some-url","state":"INPROGRESS","created_on":"2023-06-01T12:00:00Z"}`,
+			expectedPath: "/2.0/repositories/some-workspace/some-repo/commit/abc/statuses/build",
+			expectedAuth: "Basic c29tZS11c2VyOnNvbWUtcGFzc3dvcmQ=",
+		},
+		{
+			name:         "data_center_with_bearer_token",
+			inputs:       input{provider: providerBitbucketDataCenter, token: "some-token"},
+			statusCode:   http.StatusNoContent,
+			expectedPath: "/rest/build-status/1.0/commits/abc",
+			expectedAuth: "Bearer some-token",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server, request := newRecordingServer(t, c.statusCode, c.response)
+
+			c.inputs.apiURL = server.URL
+			created, _, err := newBitbucketClient(c.inputs).CreateStatus(context.Background(), "some-workspace", "some-repo", "abc", status)
+			require.NoError(t, err)
+
+			r := request()
+			require.Equal(t, c.expectedPath, r.path)
+			require.Equal(t, c.expectedAuth, r.header.Get("Authorization"))
+			require.Equal(t, map[string]any{
+				"key":         "ci",
+				"name":        "ci",
+				"url":         "https://some-url",
+				"state":       "INPROGRESS",
+				"description": "Building",
+			}, r.body)
+			require.Equal(t, int64(0), created.GetID())
+			require.Equal(t, "INPROGRESS", created.GetState())
+			require.Equal(t, "ci", created.GetContext())
+		})
+	}
+}
+
+func TestBitbucketDefaultsToTheRunURL(t *testing.T) {
+	t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+	t.Setenv("GITHUB_REPOSITORY", "foo/bar")
+	t.Setenv("GITHUB_RUN_ID", "42")
+
+	client := newBitbucketClient(input{provider: providerBitbucket})
+	r := client.statusRequest("some-workspace", "some-repo", "abc", &github.RepoStatus{State: github.String("SUCCESSFUL")})
+	require.Equal(t, "https://github.com/foo/bar/actions/runs/42", r.Body.(bitbucketStatus).URL)
+}
+
+func TestBitbucketCommitURL(t *testing.T) {
+	commit := target{owner: "some-workspace", repository: "some-repo", sha: "abc"}
+
+	cloud := newBitbucketClient(input{provider: providerBitbucket})
+	gh := ghClient{input: input{provider: providerBitbucket}, serverURL: cloud.serverURL()}
+	require.Equal(t, "https://bitbucket.org/some-workspace/some-repo/commits/abc", gh.commitURL(commit))
+
+	dataCenter := newBitbucketClient(input{provider: providerBitbucketDataCenter, apiURL: "https://bitbucket.example.com/"})
+	gh = ghClient{input: input{provider: providerBitbucketDataCenter}, serverURL: dataCenter.serverURL()}
+	require.Equal(t, "https://bitbucket.example.com/projects/some-workspace/repos/some-repo/commits/abc", gh.commitURL(commit))
+}
@@ -0,0 +1,175 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v53/github"
+)
+
+const checkRunStatusQueued = "queued"
+const checkRunStatusInProgress = "in_progress"
+const checkRunStatusCompleted = "completed"
+
+// createCheckRun creates a new GitHub check run or updates an existing one when a check run ID is set.
+func (gh *ghClient) createCheckRun(ctx context.Context) ([]statusResult, error) {
+	// The Checks API limits how many annotations can be sent at once so the first batch is sent with the check run
+	// and the rest are added with updates.
+	batches := batchAnnotations(gh.input.annotations)
+	var annotations []*github.CheckRunAnnotation
+	if len(batches) > 0 {
+		annotations = batches[0]
+	}
+
+	var checkRun *github.CheckRun
+	err := gh.withRetry(ctx, gh.input.defaultTarget(), permissionChecks, func(ctx context.Context) (*github.Response, error) {
+		var resp *github.Response
+		var err error
+		if gh.input.checkRunID != 0 {
+			checkRun, resp, err = gh.checks.UpdateCheckRun(ctx, gh.input.owner, gh.input.repository, gh.input.checkRunID, gh.updateCheckRunOptions(annotations))
+		} else {
+			checkRun, resp, err = gh.checks.CreateCheckRun(ctx, gh.input.owner, gh.input.repository, gh.createCheckRunOptions(annotations))
+		}
+		if err != nil {
+			action.Errorf("Error creating check run %v. Owner: %s, SHA: %s, Repo %s: %s", gh.input.context, gh.input.owner, gh.input.sha, gh.input.repository, err.Error())
+		}
+		return resp, err
+	})
+
+	result := statusResult{
+		target: gh.input.defaultTarget(),
+		entry:  gh.input.statusEntries()[0],
+	}
+	if err != nil {
+		result.err = err
+		return []statusResult{result}, err
+	}
+
+	// We are going to access the check run ID so make sure it is valid before proceeding.
+	if checkRun.ID == nil {
+		result.err = errors.New("check run ID returned is nil")
+		return []statusResult{result}, result.err
+	}
+	result.id = *checkRun.ID
+	result.url = checkRun.GetHTMLURL()
+	result.createdAt = checkRun.GetStartedAt().Time
+
+	for i := 1; i < len(batches); i++ {
+		err = gh.addCheckRunAnnotations(ctx, *checkRun.ID, batches[i])
+		if err != nil {
+			result.err = err
+			return []statusResult{result}, err
+		}
+	}
+
+	if gh.dryRun != nil {
+		action.Infof("Would post check run: %s as %s on %s", gh.input.context, gh.input.checkStatus, result.target)
+		return []statusResult{result}, nil
+	}
+	action.Infof("Updated check run: \nID: %d \nStatus: %s \nConclusion: %s \nAnnotations: %d \nURL: %s ", *checkRun.ID, gh.input.checkStatus, gh.input.conclusion, len(gh.input.annotations), checkRun.GetHTMLURL())
+	return []statusResult{result}, nil
+}
+
+// addCheckRunAnnotations appends a batch of annotations to an existing check run.
+func (gh *ghClient) addCheckRunAnnotations(ctx context.Context, checkRunID int64, annotations []*github.CheckRunAnnotation) error {
+	return gh.withRetry(ctx, gh.input.defaultTarget(), permissionChecks, func(ctx context.Context) (*github.Response, error) {
+		opts := github.UpdateCheckRunOptions{
+			Name:   gh.input.context,
+			Output: gh.checkRunOutput(annotations),
+		}
+		_, resp, err := gh.checks.UpdateCheckRun(ctx, gh.input.owner, gh.input.repository, checkRunID, opts)
+		if err != nil {
+			action.Errorf("Error adding annotations to check run %d. Owner: %s, Repo %s: %s", checkRunID, gh.input.owner, gh.input.repository, err.Error())
+		}
+		return resp, err
+	})
+}
+
+// createCheckRunOptions builds the options for creating a new check run from the inputs.
+func (gh *ghClient) createCheckRunOptions(annotations []*github.CheckRunAnnotation) github.CreateCheckRunOptions {
+	opts := github.CreateCheckRunOptions{
+		Name:    gh.input.context,
+		HeadSHA: gh.input.sha,
+		Status:  github.String(gh.input.checkStatus),
+		Output:  gh.checkRunOutput(annotations),
+	}
+	if gh.input.detailsURL != "" {
+		opts.DetailsURL = github.String(gh.input.detailsURL)
+	}
+	if gh.input.conclusion != "" {
+		opts.Conclusion = github.String(gh.input.conclusion)
+		opts.CompletedAt = &github.Timestamp{Time: time.Now()}
+	}
+	return opts
+}
+
+// updateCheckRunOptions builds the options for updating an existing check run from the inputs.
+func (gh *ghClient) updateCheckRunOptions(annotations []*github.CheckRunAnnotation) github.UpdateCheckRunOptions {
+	opts := github.UpdateCheckRunOptions{
+		Name:   gh.input.context,
+		Status: github.String(gh.input.checkStatus),
+		Output: gh.checkRunOutput(annotations),
+	}
+	if gh.input.detailsURL != "" {
+		opts.DetailsURL = github.String(gh.input.detailsURL)
+	}
+	if gh.input.conclusion != "" {
+		opts.Conclusion = github.String(gh.input.conclusion)
+		opts.CompletedAt = &github.Timestamp{Time: time.Now()}
+	}
+	return opts
+}
+
+// checkRunOutput builds the check run output with a batch of annotations. The title falls back to the description and
+// then the context, and the summary falls back to the description. GitHub requires both a title and a summary so no
+// output is sent without them, unless there are annotations to send.
+func (gh *ghClient) checkRunOutput(annotations []*github.CheckRunAnnotation) *github.CheckRunOutput {
+	summary := gh.input.summary
+	if summary == "" {
+		summary = gh.input.description
+	}
+	if summary == "" && len(gh.input.annotations) > 0 {
+		summary = fmt.Sprintf("%d annotations", len(gh.input.annotations))
+	}
+	if summary == "" {
+		return nil
+	}
+
+	title := gh.input.title
+	if title == "" {
+		title = gh.input.description
+	}
+	if title == "" {
+		title = gh.input.context
+	}
+
+	output := &github.CheckRunOutput{
+		Title:       github.String(title),
+		Summary:     github.String(summary),
+		Annotations: annotations,
+	}
+	if gh.input.text != "" {
+		output.Text = github.String(gh.input.text)
+	}
+	return output
+}
+
+// convertActionStateToCheckRunStatus converts the state into a check run status and conclusion. It accepts the same
+// values as convertActionStateToRepoStatusState. A state mapped by state_map is converted from the state it maps to,
+// otherwise check run conclusions are kept. A 'pending' state leaves the check run in progress without a conclusion.
+func convertActionStateToCheckRunStatus(actionState string, stateMap map[string]string) (string, string, error) {
+	key := strings.ToLower(strings.TrimSpace(actionState))
+	if state, ok := stateMap[key]; ok {
+		key = state
+	}
+	if converted, ok := checkRunStates[key]; ok {
+		return converted[0], converted[1], nil
+	}
+	return "", "", fmt.Errorf("state value not supported: %s", actionState)
+}
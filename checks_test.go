@@ -0,0 +1,259 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertActionStateToCheckRunStatus(t *testing.T) {
+	cases := []struct {
+		name               string
+		actual             string
+		expectedStatus     string
+		expectedConclusion string
+		expectError        bool
+	}{
+		{
+			name:           "pending_is_in_progress",
+			actual:         "pending",
+			expectedStatus: "in_progress",
+		},
+		{
+			name:               "success",
+			actual:             "success",
+			expectedStatus:     "completed",
+			expectedConclusion: "success",
+		},
+		{
+			name:               "error_is_failure",
+			actual:             "error",
+			expectedStatus:     "completed",
+			expectedConclusion: "failure",
+		},
+		{
+			name:               "cancelled",
+			actual:             "cancelled",
+			expectedStatus:     "completed",
+			expectedConclusion: "cancelled",
+		},
+		{
+			name:               "skipped",
+			actual:             "skipped",
+			expectedStatus:     "completed",
+			expectedConclusion: "skipped",
+		},
+		{
+			name:               "timed_out",
+			actual:             "TIMED_OUT",
+			expectedStatus:     "completed",
+			expectedConclusion: "timed_out",
+		},
+		{
+			name:           "queued",
+			actual:         "queued",
+			expectedStatus: "queued",
+		},
+		{
+			name:        "fail_with_invalid_state",
+			actual:      "foo",
+			expectError: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			status, conclusion, err := convertActionStateToCheckRunStatus(c.actual, nil)
+			assert.Equal(t, c.expectedStatus, status)
+			assert.Equal(t, c.expectedConclusion, conclusion)
+			assert.Equal(t, c.expectError, err != nil)
+		})
+	}
+}
+
+func TestCheckRunOutput(t *testing.T) {
+	cases := []struct {
+		name     string
+		inputs   input
+		expected *github.CheckRunOutput
+	}{
+		{
+			name:     "no_summary_or_description_has_no_output",
+			inputs:   input{context: "some-context", title: "some-title"},
+			expected: nil,
+		},
+		{
+			name:   "description_is_used_for_title_and_summary",
+			inputs: input{context: "some-context", description: "some-description"},
+			expected: &github.CheckRunOutput{
+				Title:   github.String("some-description"),
+				Summary: github.String("some-description"),
+			},
+		},
+		{
+			name:   "context_is_used_for_title",
+			inputs: input{context: "some-context", summary: "some-summary"},
+			expected: &github.CheckRunOutput{
+				Title:   github.String("some-context"),
+				Summary: github.String("some-summary"),
+			},
+		},
+		{
+			name:   "all_output_set",
+			inputs: input{context: "some-context", title: "some-title", summary: "some-summary", text: "some-text"},
+			expected: &github.CheckRunOutput{
+				Title:   github.String("some-title"),
+				Summary: github.String("some-summary"),
+				Text:    github.String("some-text"),
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gh := ghClient{input: c.inputs}
+			require.Equal(t, c.expected, gh.checkRunOutput(nil))
+		})
+	}
+}
+
+func TestCreateCheckRun(t *testing.T) {
+	id := int64(24601)
+	in := input{
+		token:       "some-token",
+		state:       "success",
+		context:     "some-context",
+		description: "some-description",
+		owner:       "some-owner",
+		repository:  "some-repo",
+		detailsURL:  "some-url",
+		sha:         "some-sha",
+		mode:        modeCheckRun,
+		checkStatus: "completed",
+		conclusion:  "success",
+	}
+	update := in
+	update.checkRunID = id
+
+	cases := []struct {
+		name          string
+		inputs        input
+		ghCheckClient *mockghChecksClient
+		expectError   string
+		expectUpdate  bool
+	}{
+		{
+			name:          "create",
+			inputs:        in,
+			ghCheckClient: &mockghChecksClient{checkRun: &github.CheckRun{ID: &id}, t: t, in: in},
+		},
+		{
+			name:          "update",
+			inputs:        update,
+			ghCheckClient: &mockghChecksClient{checkRun: &github.CheckRun{ID: &id}, t: t, in: update},
+			expectUpdate:  true,
+		},
+		{
+			name:          "error-nil-check-run-id",
+			inputs:        in,
+			ghCheckClient: &mockghChecksClient{checkRun: &github.CheckRun{}, t: t, in: in},
+			expectError:   "check run ID",
+		},
+		{
+			name:          "error-from-client",
+			inputs:        in,
+			ghCheckClient: &mockghChecksClient{returnError: true, t: t},
+			expectError:   "some-error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gh := ghClient{checks: c.ghCheckClient, input: c.inputs, maxConnectionRetries: uint64(0)}
+			_, err := gh.createCheckRun(context.Background())
+			if c.expectError != "" {
+				require.Contains(t, err.Error(), c.expectError)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, c.expectUpdate, c.ghCheckClient.updated)
+		})
+	}
+}
+
+type mockghChecksClient struct {
+	checkRun          *github.CheckRun
+	returnError       bool
+	updated           bool
+	annotationBatches []int
+	t                 *testing.T
+
+	in input
+}
+
+func TestCreateCheckRunBatchesAnnotations(t *testing.T) {
+	id := int64(24601)
+	in := input{
+		context:     "some-context",
+		owner:       "some-owner",
+		repository:  "some-repo",
+		sha:         "some-sha",
+		mode:        modeCheckRun,
+		checkStatus: "completed",
+		conclusion:  "failure",
+	}
+	for i := 0; i < 120; i++ {
+		in.annotations = append(in.annotations, newAnnotation("main.go", i+1, i+1, 0, annotationLevelFailure, "some-message", ""))
+	}
+
+	mock := &mockghChecksClient{checkRun: &github.CheckRun{ID: &id}, t: t, in: in}
+	gh := ghClient{checks: mock, input: in, maxConnectionRetries: uint64(0)}
+	_, err := gh.createCheckRun(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []int{50, 20}, mock.annotationBatches)
+}
+
+func (m *mockghChecksClient) CreateCheckRun(_ context.Context, owner, repo string, opts github.CreateCheckRunOptions) (*github.CheckRun, *github.Response, error) {
+	if m.returnError {
+		return nil, nil, errors.New("some-error")
+	}
+
+	assert.Equal(m.t, m.in.owner, owner)
+	assert.Equal(m.t, m.in.repository, repo)
+	assert.Equal(m.t, m.in.sha, opts.HeadSHA)
+	assert.Equal(m.t, m.in.context, opts.Name)
+	assert.Equal(m.t, m.in.checkStatus, opts.GetStatus())
+	assert.Equal(m.t, m.in.conclusion, opts.GetConclusion())
+	assert.Equal(m.t, m.in.detailsURL, opts.GetDetailsURL())
+	if len(m.in.annotations) > 0 {
+		assert.Len(m.t, opts.Output.Annotations, maxAnnotationsPerRequest)
+	}
+
+	return m.checkRun, nil, nil
+}
+
+func (m *mockghChecksClient) UpdateCheckRun(_ context.Context, owner, repo string, checkRunID int64, opts github.UpdateCheckRunOptions) (*github.CheckRun, *github.Response, error) {
+	if m.returnError {
+		return nil, nil, errors.New("some-error")
+	}
+	// Updates without a status only add annotations
+	if opts.Status == nil {
+		m.annotationBatches = append(m.annotationBatches, len(opts.Output.Annotations))
+		return m.checkRun, nil, nil
+	}
+	m.updated = true
+
+	assert.Equal(m.t, m.in.owner, owner)
+	assert.Equal(m.t, m.in.repository, repo)
+	assert.Equal(m.t, m.in.checkRunID, checkRunID)
+	assert.Equal(m.t, m.in.context, opts.Name)
+	assert.Equal(m.t, m.in.checkStatus, opts.GetStatus())
+	assert.Equal(m.t, m.in.conclusion, opts.GetConclusion())
+
+	return m.checkRun, nil, nil
+}
@@ -0,0 +1,359 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// The formats of the CLI output.
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// cliCommands are the commands of the CLI. They are the values of the command input.
+var cliCommands = []struct {
+	name  string
+	usage string
+}{
+	{commandSet, "Post statuses or check runs, or run the command after -- between a pending status and its outcome"},
+	{commandGet, "Print the latest status of a context"},
+	{commandList, "Print the latest status of every context"},
+	{commandWait, "Wait until statuses finish"},
+	{commandRollup, "Post a status that aggregates other statuses"},
+}
+
+// cliInputs are the inputs that can be set with flags. Each flag is named after its input with dashes instead of
+// underscores. The command and run inputs come from the arguments and lifecycle only works in a workflow.
+var cliInputs = []struct {
+	name   string
+	usage  string
+	isBool bool
+}{
+	{name: "provider", usage: "Where to post the statuses: github, gitlab, bitbucket, bitbucket-datacenter or gitea"},
+	{name: "token", usage: "Access token, GITHUB_TOKEN when not set"},
+	{name: "username", usage: "Bitbucket username to send the token with as an app password"},
+	{name: "app_id", usage: "ID of a GitHub App to authenticate as instead of using a token"},
+	{name: "private_key", usage: "Private key of the GitHub App"},
+	{name: "installation_id", usage: "Installation ID of the GitHub App"},
+	{name: "api_url", usage: "API URL, for example https://ghes.example.com/api/v3"},
+	{name: "pipeline_id", usage: "GitLab pipeline to add the statuses to"},
+	{name: "owner", usage: "Repository owner"},
+	{name: "repository", usage: "Repository, either repo or owner/repo"},
+	{name: "sha", usage: "Commit: a SHA, short SHA, branch, tag or pr:<number>"},
+	{name: "sha_source", usage: "Where the SHA comes from when sha is not set: event or github_sha"},
+	{name: "state", usage: "State of the status"},
+	{name: "state_map", usage: "Comma separated from=to state mappings"},
+	{name: "context", usage: "Context of the status, default when not set with set"},
+	{name: "description", usage: "Description of the status, can be a template"},
+	{name: "details_url", usage: "Details URL of the status, can be a template"},
+	{name: "contexts", usage: "Comma separated contexts to wait for, or patterns of the contexts to roll up or list"},
+	{name: "timeout", usage: "Longest time to wait for the statuses"},
+	{name: "poll_interval", usage: "First interval between polls while waiting"},
+	{name: "idempotent", usage: "Skip statuses that are already current", isBool: true},
+	{name: "dry_run", usage: "Print the requests instead of sending them", isBool: true},
+	{name: "description_overflow", usage: "What to do with descriptions over 140 characters: truncate or error"},
+	{name: "template", usage: "Render description and details_url as Go templates", isBool: true},
+	{name: "values_file", usage: "JSON file with values that templates can use as .Values"},
+	{name: "statuses", usage: "YAML or JSON list of statuses to post"},
+	{name: "targets", usage: "Comma separated owner/repo@ref commits to post the statuses to"},
+	{name: "max_concurrency", usage: "Number of statuses posted at the same time"},
+	{name: "max_rate_limit_wait", usage: "Longest time to wait for a rate limit to reset"},
+	{name: "mode", usage: "status or check-run"},
+	{name: "title", usage: "Title of the check run output"},
+	{name: "summary", usage: "Markdown summary of the check run output"},
+	{name: "text", usage: "Markdown details of the check run output"},
+	{name: "check_run_id", usage: "ID of a check run to update"},
+	{name: "annotations_file", usage: "Compiler or linter output to add to the check run as annotations"},
+	{name: "annotations_format", usage: "Format of the annotations file"},
+	{name: "annotations_matcher", usage: "Problem matcher file used to parse the annotations file"},
+}
+
+// inputFlag is a flag that sets an input. Inputs whose flag was not given fall back to the environment.
+type inputFlag struct {
+	value  string
+	set    bool
+	isBool bool
+}
+
+func (f *inputFlag) String() string {
+	return f.value
+}
+
+func (f *inputFlag) Set(value string) error {
+	f.value = value
+	f.set = true
+	return nil
+}
+
+func (f *inputFlag) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// cliOptions are the parsed arguments of a CLI command.
+type cliOptions struct {
+	output   string
+	getInput getInputFunc
+}
+
+// isCLICommand reports whether the first argument is a CLI command rather than a run of the action.
+func isCLICommand(arg string) bool {
+	if arg == "help" {
+		return true
+	}
+	for _, c := range cliCommands {
+		if c.name == arg {
+			return true
+		}
+	}
+	return false
+}
+
+// runCLI runs a command of the CLI and returns its exit code. It goes through the same inputs, defaults and
+// validation as the action, with the flags in front of getInput.
+func runCLI(ctx context.Context, command string, args []string, getInput getInputFunc, stdout, stderr io.Writer) int {
+	if command == "help" {
+		printCLIUsage(stdout)
+		return 0
+	}
+
+	opts, err := parseCLI(command, args, getInput, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		// The error and usage were already printed
+		return 2
+	}
+
+	client, err := newGHClient(ctx, uint64(5), opts.getInput)
+	if err != nil {
+		return cliError(stderr, err)
+	}
+	if client.input.dryRun {
+		client.enableDryRun()
+	}
+	if err := client.resolveRefs(ctx); err != nil {
+		return cliError(stderr, err)
+	}
+
+	switch client.input.subcommand {
+	case commandGet, commandList:
+		listing, err := client.listStatuses(ctx)
+		if err != nil {
+			return cliError(stderr, err)
+		}
+		printListing(stdout, opts.output, listing, client.input.subcommand == commandGet)
+		return 0
+	case commandWait:
+		result, err := client.waitForStatuses(ctx)
+		if err != nil {
+			return cliError(stderr, err)
+		}
+		printWait(stdout, opts.output, result)
+		if err := result.err(client.input.waitTimeoutOrDefault()); err != nil {
+			return cliError(stderr, err)
+		}
+		return 0
+	case commandRollup:
+		results, err := client.rollup(ctx)
+		client.printResults(stdout, opts.output, results)
+		if err != nil {
+			return cliError(stderr, err)
+		}
+		return 0
+	}
+
+	if len(client.input.command) > 0 {
+		// JSON on stdout must not be mixed with the output of the command
+		commandStdout := stdout
+		if opts.output == outputJSON {
+			commandStdout = stderr
+		}
+		res, results, err := client.wrapCommand(ctx, commandStdout, stderr)
+		client.printResults(stdout, opts.output, results)
+		if err != nil {
+			return cliError(stderr, err)
+		}
+		return res.exitCode
+	}
+
+	results, err := client.publish(ctx)
+	client.printResults(stdout, opts.output, results)
+	if err != nil {
+		return cliError(stderr, err)
+	}
+	return 0
+}
+
+// parseCLI parses the flags of a command. The returned getInput reads the inputs from the flags and falls back to
+// getInput, so INPUT_* variables still work, and the token falls back to GITHUB_TOKEN. A command to run can follow --
+// with set. Errors are printed together with the usage, the way the flag package prints its own.
+func parseCLI(command string, args []string, getInput getInputFunc, stderr io.Writer) (cliOptions, error) {
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	output := fs.String("output", outputText, "Output format: text or json")
+	flags := map[string]*inputFlag{}
+	for _, in := range cliInputs {
+		f := &inputFlag{isBool: in.isBool}
+		fs.Var(f, strings.ReplaceAll(in.name, "_", "-"), in.usage)
+		flags[in.name] = f
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: commit-status-action %s [flags]", command)
+		if command == commandSet {
+			fmt.Fprint(stderr, " [-- command [args...]]")
+		}
+		fmt.Fprintf(stderr, "\n\n%s.\n\nFlags:\n", cliCommandUsage(command))
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return cliOptions{}, err
+	}
+
+	fail := func(err error) (cliOptions, error) {
+		fmt.Fprintln(stderr, err)
+		fs.Usage()
+		return cliOptions{}, err
+	}
+	if *output != outputText && *output != outputJSON {
+		return fail(fmt.Errorf("output value not supported: %s", *output))
+	}
+	// Only arguments after -- are a command, anything else is a mistake
+	run := fs.Args()
+	if len(run) > 0 {
+		if i := len(args) - len(run); i == 0 || args[i-1] != "--" {
+			return fail(fmt.Errorf("unexpected argument: %s", run[0]))
+		}
+		if command != commandSet {
+			return fail(fmt.Errorf("a command can only be run with %s", commandSet))
+		}
+	}
+
+	opts := cliOptions{output: *output}
+	opts.getInput = func(name string) string {
+		if f, ok := flags[name]; ok && f.set {
+			return f.value
+		}
+		if name == "command" {
+			return command
+		}
+		value := getInput(name)
+		// The same defaults as the action. get and rollup need to be told the context, so it only has a default for set.
+		switch {
+		case name == "token" && value == "":
+			value = os.Getenv("GITHUB_TOKEN")
+		case name == "context" && value == "" && command == commandSet:
+			value = defaultContext
+		}
+		return value
+	}
+	if len(run) > 0 {
+		opts.getInput = withRunInput(opts.getInput, run)
+	}
+	return opts, nil
+}
+
+// cliCommandUsage returns the usage of a command.
+func cliCommandUsage(command string) string {
+	for _, c := range cliCommands {
+		if c.name == command {
+			return c.usage
+		}
+	}
+	return ""
+}
+
+// printCLIUsage prints the commands of the CLI.
+func printCLIUsage(w io.Writer) {
+	fmt.Fprint(w, "Usage: commit-status-action <command> [flags]\n\nCommands:\n")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range cliCommands {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.name, c.usage)
+	}
+	tw.Flush()
+	fmt.Fprint(w, "\nRun commit-status-action <command> -h for the flags of a command.\n")
+}
+
+// cliError prints the error and returns the exit code of a failed command.
+func cliError(stderr io.Writer, err error) int {
+	fmt.Fprintf(stderr, "Error: %s\n", err)
+	return 1
+}
+
+// printResults prints the statuses that were posted, or the requests when it is a dry run.
+func (gh *ghClient) printResults(w io.Writer, output string, results []statusResult) {
+	if gh.dryRun != nil {
+		fmt.Fprintln(w, gh.dryRun.json())
+		return
+	}
+	if output == outputJSON {
+		results := gh.statusOutputs(results)["results"]
+		if results == "" {
+			results = "[]"
+		}
+		fmt.Fprintln(w, results)
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONTEXT\tSTATE\tCOMMIT\tRESULT")
+	for _, r := range results {
+		result := r.url
+		switch {
+		case r.err != nil:
+			result = r.err.Error()
+		case r.skipped:
+			result = "unchanged"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.entry.Context, r.entry.State, r.target, result)
+	}
+	tw.Flush()
+}
+
+// printListing prints the statuses read by get or list. In JSON get prints a single status and list prints a list.
+func printListing(w io.Writer, output string, listing statusListing, single bool) {
+	list := listing.list()
+	if output == outputJSON {
+		var v any = list
+		if single && len(list) == 1 {
+			v = list[0]
+		}
+		fmt.Fprintln(w, marshalIndentJSON(v))
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONTEXT\tSTATE\tDESCRIPTION\tDETAILS URL")
+	for _, s := range list {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Context, s.State, s.Description, s.DetailsURL)
+	}
+	tw.Flush()
+}
+
+// printWait prints the state of every context that was waited for.
+func printWait(w io.Writer, output string, result waitResult) {
+	if output == outputJSON {
+		fmt.Fprintln(w, marshalIndentJSON(struct {
+			State    string            `json:"state"`
+			Statuses map[string]string `json:"statuses"`
+		}{result.state, result.states}))
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONTEXT\tSTATE")
+	for _, statusContext := range result.contexts {
+		fmt.Fprintf(tw, "%s\t%s\n", statusContext, result.states[statusContext])
+	}
+	tw.Flush()
+}
@@ -0,0 +1,235 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsCLICommand(t *testing.T) {
+	for _, arg := range []string{"set", "get", "list", "wait", "rollup", "help"} {
+		require.True(t, isCLICommand(arg), arg)
+	}
+	for _, arg := range []string{"", "--phase=pre", "--", "foo"} {
+		require.False(t, isCLICommand(arg), arg)
+	}
+}
+
+func TestParseCLI(t *testing.T) {
+	env := map[string]string{
+		"context": "env-context",
+		"state":   "env-state",
+		"command": "env-command",
+	}
+	cases := []struct {
+		name           string
+		command        string
+		args           []string
+		githubToken    string
+		env            map[string]string
+		expectedInputs map[string]string
+		expectedOutput string
+		expectError    bool
+	}{
+		{
+			name:    "flags_set_inputs",
+			command: commandSet,
+			args:    []string{"--state", "success", "--details-url=https://some-url", "--dry-run"},
+			expectedInputs: map[string]string{
+				"state":       "success",
+				"details_url": "https://some-url",
+				"dry_run":     "true",
+			},
+			expectedOutput: outputText,
+		},
+		{
+			name:    "inputs_fall_back_to_environment",
+			command: commandSet,
+			args:    []string{"--output", "json"},
+			expectedInputs: map[string]string{
+				"context":    "env-context",
+				"state":      "env-state",
+				"idempotent": "",
+			},
+			expectedOutput: outputJSON,
+		},
+		{
+			name:    "command_input_is_the_command",
+			command: commandWait,
+			expectedInputs: map[string]string{
+				"command": commandWait,
+			},
+			expectedOutput: outputText,
+		},
+		{
+			name:        "token_falls_back_to_github_token",
+			command:     commandList,
+			githubToken: "some-token",
+			expectedInputs: map[string]string{
+				"token": "some-token",
+			},
+			expectedOutput: outputText,
+		},
+		{
+			name:    "context_defaults_like_the_action",
+			command: commandSet,
+			env:     map[string]string{},
+			expectedInputs: map[string]string{
+				"context": defaultContext,
+			},
+			expectedOutput: outputText,
+		},
+		{
+			name:    "context_required_with_get",
+			command: commandGet,
+			env:     map[string]string{},
+			expectedInputs: map[string]string{
+				"context": "",
+			},
+			expectedOutput: outputText,
+		},
+		{
+			name:    "token_flag_wins",
+			command: commandList,
+			args:    []string{"--token", "flag-token"},
+			expectedInputs: map[string]string{
+				"token": "flag-token",
+			},
+			githubToken:    "some-token",
+			expectedOutput: outputText,
+		},
+		{
+			name:    "run_after_dashes",
+			command: commandSet,
+			args:    []string{"--context", "test", "--", "go", "test", "-run", "it's"},
+			expectedInputs: map[string]string{
+				"context": "test",
+				"run":     `'go' 'test' '-run' 'it'\''s'`,
+			},
+			expectedOutput: outputText,
+		},
+		{
+			name:        "run_only_with_set",
+			command:     commandGet,
+			args:        []string{"--", "true"},
+			expectError: true,
+		},
+		{
+			name:        "unexpected_argument",
+			command:     commandSet,
+			args:        []string{"--state", "success", "foo"},
+			expectError: true,
+		},
+		{
+			name:        "unknown_flag",
+			command:     commandSet,
+			args:        []string{"--foo"},
+			expectError: true,
+		},
+		{
+			name:        "unsupported_output",
+			command:     commandSet,
+			args:        []string{"--output", "yaml"},
+			expectError: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Setenv("GITHUB_TOKEN", c.githubToken)
+			var stderr bytes.Buffer
+			inputs := env
+			if c.env != nil {
+				inputs = c.env
+			}
+			opts, err := parseCLI(c.command, c.args, func(name string) string { return inputs[name] }, &stderr)
+			if c.expectError {
+				require.Error(t, err)
+				require.Contains(t, stderr.String(), "Usage: commit-status-action "+c.command)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expectedOutput, opts.output)
+			for name, value := range c.expectedInputs {
+				require.Equal(t, value, opts.getInput(name), name)
+			}
+		})
+	}
+}
+
+func TestParseCLIHelp(t *testing.T) {
+	var stderr bytes.Buffer
+	_, err := parseCLI(commandWait, []string{"-h"}, func(string) string { return "" }, &stderr)
+	require.True(t, errors.Is(err, flag.ErrHelp))
+	require.Contains(t, stderr.String(), "Wait until statuses finish.")
+	require.Contains(t, stderr.String(), "-poll-interval")
+}
+
+func TestPrintResults(t *testing.T) {
+	results := []statusResult{
+		{
+			target: target{owner: "foo", repository: "bar", sha: "abc"},
+			entry:  statusEntry{Context: "lint", State: "success"},
+			id:     24601,
+			url:    "https://api.github.com/repos/foo/bar/statuses/abc",
+		},
+		{
+			target:  target{owner: "foo", repository: "bar", sha: "abc"},
+			entry:   statusEntry{Context: "unit", State: "pending"},
+			skipped: true,
+		},
+		{
+			target: target{owner: "foo", repository: "baz", sha: "def"},
+			entry:  statusEntry{Context: "lint", State: "success"},
+			err:    errors.New("some-error"),
+		},
+	}
+	gh := ghClient{}
+
+	var text bytes.Buffer
+	gh.printResults(&text, outputText, results)
+	require.Equal(t, "CONTEXT  STATE    COMMIT       RESULT\n"+
+		"lint     success  foo/bar@abc  https://api.github.com/repos/foo/bar/statuses/abc\n"+
+		"unit     pending  foo/bar@abc  unchanged\n"+
+		"lint     success  foo/baz@def  some-error\n", text.String())
+
+	var jsonOutput bytes.Buffer
+	gh.printResults(&jsonOutput, outputJSON, nil)
+	require.Equal(t, "[]\n", jsonOutput.String())
+}
+
+func TestPrintListing(t *testing.T) {
+	listing := statusListing{statuses: []*github.RepoStatus{
+		{Context: github.String("lint"), State: github.String("success"), Description: github.String("Lint passed")},
+	}}
+
+	var text bytes.Buffer
+	printListing(&text, outputText, listing, false)
+	require.Equal(t, "CONTEXT  STATE    DESCRIPTION  DETAILS URL\nlint     success  Lint passed  \n", text.String())
+
+	var list bytes.Buffer
+	printListing(&list, outputJSON, listing, false)
+	require.JSONEq(t, `[{"context":"lint","state":"success","description":"Lint passed"}]`, list.String())
+
+	var single bytes.Buffer
+	printListing(&single, outputJSON, listing, true)
+	require.JSONEq(t, `{"context":"lint","state":"success","description":"Lint passed"}`, single.String())
+}
+
+func TestPrintWait(t *testing.T) {
+	result := evaluateWait([]string{"lint", "unit"}, map[string]string{"lint": "success"})
+
+	var text bytes.Buffer
+	printWait(&text, outputText, result)
+	require.Equal(t, "CONTEXT  STATE\nlint     success\nunit     pending\n", text.String())
+
+	var jsonOutput bytes.Buffer
+	printWait(&jsonOutput, outputJSON, result)
+	require.JSONEq(t, `{"state":"pending","statuses":{"lint":"success","unit":"pending"}}`, jsonOutput.String())
+}
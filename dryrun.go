@@ -0,0 +1,102 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/go-github/v53/github"
+)
+
+const dryRunRunErr = "run is not supported with dry_run"
+
+// dryRunRequest is a request that would have been sent to the GitHub API.
+type dryRunRequest struct {
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Owner      string `json:"owner"`
+	Repository string `json:"repository"`
+	SHA        string `json:"sha,omitempty"`
+	Body       any    `json:"body"`
+}
+
+// dryRunClient records the statuses and check runs that would be created instead of creating them. It stands in for
+// the repositories and checks clients so everything up to the request runs as usual.
+type dryRunClient struct {
+	mu       sync.Mutex
+	requests []dryRunRequest
+	// provider describes the requests of providers other than GitHub.
+	provider statusRequester
+}
+
+func (d *dryRunClient) CreateStatus(_ context.Context, owner, repo, ref string, status *github.RepoStatus) (*github.RepoStatus, *github.Response, error) {
+	if d.provider != nil {
+		d.record(d.provider.statusRequest(owner, repo, ref, status))
+		return &github.RepoStatus{ID: github.Int64(0)}, nil, nil
+	}
+	d.record(dryRunRequest{
+		Method:     "POST",
+		Path:       fmt.Sprintf("repos/%s/%s/statuses/%s", owner, repo, ref),
+		Owner:      owner,
+		Repository: repo,
+		SHA:        ref,
+		Body:       status,
+	})
+	return &github.RepoStatus{ID: github.Int64(0)}, nil, nil
+}
+
+func (d *dryRunClient) CreateCheckRun(_ context.Context, owner, repo string, opts github.CreateCheckRunOptions) (*github.CheckRun, *github.Response, error) {
+	d.record(dryRunRequest{
+		Method:     "POST",
+		Path:       fmt.Sprintf("repos/%s/%s/check-runs", owner, repo),
+		Owner:      owner,
+		Repository: repo,
+		SHA:        opts.HeadSHA,
+		Body:       opts,
+	})
+	return &github.CheckRun{ID: github.Int64(0)}, nil, nil
+}
+
+func (d *dryRunClient) UpdateCheckRun(_ context.Context, owner, repo string, checkRunID int64, opts github.UpdateCheckRunOptions) (*github.CheckRun, *github.Response, error) {
+	d.record(dryRunRequest{
+		Method:     "PATCH",
+		Path:       fmt.Sprintf("repos/%s/%s/check-runs/%d", owner, repo, checkRunID),
+		Owner:      owner,
+		Repository: repo,
+		Body:       opts,
+	})
+	return &github.CheckRun{ID: github.Int64(checkRunID)}, nil, nil
+}
+
+func (d *dryRunClient) record(req dryRunRequest) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.requests = append(d.requests, req)
+}
+
+// json returns the recorded requests as indented JSON.
+func (d *dryRunClient) json() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	requests := d.requests
+	if requests == nil {
+		requests = []dryRunRequest{}
+	}
+	return marshalIndentJSON(requests)
+}
+
+// enableDryRun replaces the clients that create statuses and check runs with a recording client. Statuses are posted
+// one at a time so the requests are recorded in a stable order.
+func (gh *ghClient) enableDryRun() {
+	gh.dryRun = &dryRunClient{}
+	if provider, ok := gh.client.(statusRequester); ok {
+		gh.dryRun.provider = provider
+	}
+	gh.client = gh.dryRun
+	gh.checks = gh.dryRun
+	gh.input.maxConcurrency = 1
+}
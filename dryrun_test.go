@@ -0,0 +1,159 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	cases := []struct {
+		name     string
+		inputs   input
+		expected string
+	}{
+		{
+			name: "status",
+			inputs: input{
+				owner:       "some-owner",
+				repository:  "some-repo",
+				sha:         "some-sha",
+				state:       "success",
+				context:     "some-context",
+				description: "some-description",
+				detailsURL:  "https://some-url",
+			},
+			expected: `[
+  {
+    "method": "POST",
+    "path": "repos/some-owner/some-repo/statuses/some-sha",
+    "owner": "some-owner",
+    "repository": "some-repo",
+    "sha": "some-sha",
+    "body": {
+      "state": "success",
+      "target_url": "https://some-url",
+      "description": "some-description",
+      "context": "some-context"
+    }
+  }
+]`,
+		},
+		{
+			name: "statuses_on_targets_in_order",
+			inputs: input{
+				statuses: []statusEntry{
+					{Context: "lint", State: "pending"},
+					{Context: "unit", State: "pending"},
+				},
+				targets: []target{
+					{owner: "foo", repository: "bar", sha: "abc"},
+					{owner: "foo", repository: "baz", sha: "def"},
+				},
+				maxConcurrency: 4,
+			},
+			expected: `[
+  {
+    "method": "POST",
+    "path": "repos/foo/bar/statuses/abc",
+    "owner": "foo",
+    "repository": "bar",
+    "sha": "abc",
+    "body": {
+      "state": "pending",
+      "target_url": "",
+      "description": "",
+      "context": "lint"
+    }
+  },
+  {
+    "method": "POST",
+    "path": "repos/foo/bar/statuses/abc",
+    "owner": "foo",
+    "repository": "bar",
+    "sha": "abc",
+    "body": {
+      "state": "pending",
+      "target_url": "",
+      "description": "",
+      "context": "unit"
+    }
+  },
+  {
+    "method": "POST",
+    "path": "repos/foo/baz/statuses/def",
+    "owner": "foo",
+    "repository": "baz",
+    "sha": "def",
+    "body": {
+      "state": "pending",
+      "target_url": "",
+      "description": "",
+      "context": "lint"
+    }
+  },
+  {
+    "method": "POST",
+    "path": "repos/foo/baz/statuses/def",
+    "owner": "foo",
+    "repository": "baz",
+    "sha": "def",
+    "body": {
+      "state": "pending",
+      "target_url": "",
+      "description": "",
+      "context": "unit"
+    }
+  }
+]`,
+		},
+		{
+			name: "check_run",
+			inputs: input{
+				owner:       "some-owner",
+				repository:  "some-repo",
+				sha:         "some-sha",
+				mode:        modeCheckRun,
+				context:     "some-context",
+				description: "some-description",
+				checkStatus: "in_progress",
+			},
+			expected: `[
+  {
+    "method": "POST",
+    "path": "repos/some-owner/some-repo/check-runs",
+    "owner": "some-owner",
+    "repository": "some-repo",
+    "sha": "some-sha",
+    "body": {
+      "name": "some-context",
+      "head_sha": "some-sha",
+      "status": "in_progress",
+      "output": {
+        "title": "some-description",
+        "summary": "some-description"
+      }
+    }
+  }
+]`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gh := ghClient{input: c.inputs}
+			gh.enableDryRun()
+			_, err := gh.publish(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, c.expected, gh.dryRun.json())
+		})
+	}
+}
+
+func TestDryRunWithoutRequests(t *testing.T) {
+	d := &dryRunClient{}
+	require.Equal(t, "[]", d.json())
+}
@@ -0,0 +1,84 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v53/github"
+)
+
+const defaultAPIURL = "https://api.github.com"
+const defaultServerURL = "https://github.com"
+
+// getAPIURL returns the GitHub API URL. An explicit api_url input takes precedence over GITHUB_API_URL, which is set by
+// the runner on both github.com and GitHub Enterprise Server.
+func getAPIURL(apiURL string) string {
+	if apiURL != "" {
+		return strings.TrimSuffix(apiURL, "/")
+	}
+	if apiURL = os.Getenv("GITHUB_API_URL"); apiURL != "" {
+		return strings.TrimSuffix(apiURL, "/")
+	}
+	return defaultAPIURL
+}
+
+// getServerURL returns the GitHub web URL used to build links. GITHUB_SERVER_URL is used unless an explicit api_url
+// input is set, in which case the web URL is derived from it so links point at the same host as the API.
+func getServerURL(apiURLInput, apiURL string) string {
+	if serverURL := os.Getenv("GITHUB_SERVER_URL"); serverURL != "" && apiURLInput == "" {
+		return strings.TrimSuffix(serverURL, "/")
+	}
+	return serverURLFromAPIURL(apiURL)
+}
+
+// serverURLFromAPIURL derives the web URL from an API URL. GitHub Enterprise Server serves the API from /api/v3 on
+// the same host while github.com and GitHub Enterprise Cloud serve it from an api. subdomain.
+func serverURLFromAPIURL(apiURL string) string {
+	u, err := url.Parse(apiURL)
+	if err != nil || u.Host == "" {
+		return defaultServerURL
+	}
+	u.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/api/v3")
+	if u.Path == "" {
+		u.Host = strings.TrimPrefix(u.Host, "api.")
+	}
+	u.RawQuery = ""
+	u.Fragment = ""
+	return strings.TrimSuffix(u.String(), "/")
+}
+
+// newGitHubClient creates a GitHub client for the API URL. github.com uses the default client while every other host
+// is treated as a GitHub Enterprise instance.
+func newGitHubClient(httpClient *http.Client, apiURL string) (*github.Client, error) {
+	if apiURL == defaultAPIURL {
+		return github.NewClient(httpClient), nil
+	}
+	client, err := github.NewEnterpriseClient(apiURL, serverURLFromAPIURL(apiURL), httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("api_url is not a valid URL: %w", err)
+	}
+	return client, nil
+}
+
+// commitURL returns the web URL of the target commit.
+func (gh *ghClient) commitURL(t target) string {
+	serverURL := gh.serverURL
+	if serverURL == "" {
+		serverURL = defaultServerURL
+	}
+	switch gh.input.provider {
+	case providerGitLab:
+		return fmt.Sprintf("%s/%s/%s/-/commit/%s", serverURL, t.owner, t.repository, t.sha)
+	case providerBitbucketDataCenter:
+		return fmt.Sprintf("%s/projects/%s/repos/%s/commits/%s", serverURL, t.owner, t.repository, t.sha)
+	case providerGitea:
+		return fmt.Sprintf("%s/%s/%s/commit/%s", serverURL, t.owner, t.repository, t.sha)
+	}
+	return fmt.Sprintf("%s/%s/%s/commits/%s", serverURL, t.owner, t.repository, t.sha)
+}
@@ -0,0 +1,97 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAPIAndServerURL(t *testing.T) {
+	cases := []struct {
+		name              string
+		apiURLInput       string
+		envAPIURL         string
+		envServerURL      string
+		expectedAPIURL    string
+		expectedServerURL string
+	}{
+		{
+			name:              "defaults_to_github_com",
+			expectedAPIURL:    "https://api.github.com",
+			expectedServerURL: "https://github.com",
+		},
+		{
+			name:              "environment",
+			envAPIURL:         "https://ghes.example.com/api/v3",
+			envServerURL:      "https://ghes.example.com",
+			expectedAPIURL:    "https://ghes.example.com/api/v3",
+			expectedServerURL: "https://ghes.example.com",
+		},
+		{
+			name:              "server_url_derived_from_environment_api_url",
+			envAPIURL:         "https://ghes.example.com/api/v3/",
+			expectedAPIURL:    "https://ghes.example.com/api/v3",
+			expectedServerURL: "https://ghes.example.com",
+		},
+		{
+			name:              "input_overrides_environment",
+			apiURLInput:       "https://ghes.example.com/api/v3",
+			envAPIURL:         "https://api.github.com",
+			envServerURL:      "https://github.com",
+			expectedAPIURL:    "https://ghes.example.com/api/v3",
+			expectedServerURL: "https://ghes.example.com",
+		},
+		{
+			name:              "api_subdomain",
+			apiURLInput:       "https://api.octocorp.ghe.com",
+			expectedAPIURL:    "https://api.octocorp.ghe.com",
+			expectedServerURL: "https://octocorp.ghe.com",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Setenv("GITHUB_API_URL", c.envAPIURL)
+			t.Setenv("GITHUB_SERVER_URL", c.envServerURL)
+
+			apiURL := getAPIURL(c.apiURLInput)
+			require.Equal(t, c.expectedAPIURL, apiURL)
+			require.Equal(t, c.expectedServerURL, getServerURL(c.apiURLInput, apiURL))
+		})
+	}
+}
+
+func TestNewGitHubClient(t *testing.T) {
+	cases := []struct {
+		name            string
+		apiURL          string
+		expectedBaseURL string
+	}{
+		{
+			name:            "github_com",
+			apiURL:          "https://api.github.com",
+			expectedBaseURL: "https://api.github.com/",
+		},
+		{
+			name:            "enterprise_server",
+			apiURL:          "https://ghes.example.com/api/v3",
+			expectedBaseURL: "https://ghes.example.com/api/v3/",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client, err := newGitHubClient(http.DefaultClient, c.apiURL)
+			require.NoError(t, err)
+			require.Equal(t, c.expectedBaseURL, client.BaseURL.String())
+		})
+	}
+}
+
+func TestCommitURL(t *testing.T) {
+	commit := target{owner: "foo", repository: "bar", sha: "abc123"}
+	require.Equal(t, "https://github.com/foo/bar/commits/abc123", (&ghClient{}).commitURL(commit))
+	require.Equal(t, "https://ghes.example.com/foo/bar/commits/abc123", (&ghClient{serverURL: "https://ghes.example.com"}).commitURL(commit))
+}
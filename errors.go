@@ -0,0 +1,101 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v53/github"
+)
+
+const permissionStatuses = "repo:status"
+const permissionChecks = "checks:write"
+const permissionActions = "actions:read"
+const permissionContents = "contents:read"
+const permissionPullRequests = "pull-requests:read"
+
+// The kinds of permanent API errors. They can be matched with errors.Is.
+var (
+	errUnauthorized = errors.New("unauthorized")
+	errForbidden    = errors.New("forbidden")
+	errNotFound     = errors.New("not found")
+	errInvalid      = errors.New("invalid request")
+)
+
+// apiError is a permanent GitHub API error with a message that explains how to fix it.
+type apiError struct {
+	kind       error
+	statusCode int
+	message    string
+	err        error
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s: %s", e.message, e.err)
+}
+
+// Is matches the kind of the error. It is used instead of unwrapping to several errors, which errors.Is only follows
+// from Go 1.20.
+func (e *apiError) Is(target error) bool {
+	return target == e.kind
+}
+
+// Unwrap returns the original error so it can still be matched.
+func (e *apiError) Unwrap() error {
+	return e.err
+}
+
+// classifyError returns an apiError for errors that will not succeed when retried and nil for transient errors. Only
+// server errors, timeouts and network errors are transient, rate limits are handled before errors are classified.
+func classifyError(err error, t target, permission string) *apiError {
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return nil
+	}
+
+	statusCode := errResp.Response.StatusCode
+	newErr := func(kind error, format string, args ...interface{}) *apiError {
+		return &apiError{kind: kind, statusCode: statusCode, message: fmt.Sprintf(format, args...), err: err}
+	}
+	repo := fmt.Sprintf("%s/%s", t.owner, t.repository)
+
+	switch {
+	case statusCode >= http.StatusInternalServerError, statusCode == http.StatusRequestTimeout, statusCode == http.StatusTooManyRequests:
+		return nil
+	case statusCode == http.StatusUnauthorized:
+		return newErr(errUnauthorized, "token is invalid or has expired")
+	case statusCode == http.StatusForbidden:
+		return newErr(errForbidden, "token lacks %s on %s", permission, repo)
+	case statusCode == http.StatusNotFound:
+		return newErr(errNotFound, "repository %s not found or the token has no access to it", repo)
+	case statusCode == http.StatusUnprocessableEntity:
+		return newErr(errInvalid, "%s", unprocessableMessage(errResp, t))
+	default:
+		return newErr(errInvalid, "request to %s was rejected with status %d", repo, statusCode)
+	}
+}
+
+// unprocessableMessage explains why GitHub rejected a request with a 422 validation error.
+func unprocessableMessage(errResp *github.ErrorResponse, t target) string {
+	if strings.Contains(strings.ToLower(errResp.Message), "no commit found") {
+		return fmt.Sprintf("SHA %s not found in repository %s/%s", t.sha, t.owner, t.repository)
+	}
+
+	var details []string
+	for _, e := range errResp.Errors {
+		switch {
+		case e.Message != "":
+			details = append(details, e.Message)
+		case e.Field != "":
+			details = append(details, fmt.Sprintf("%s is %s", e.Field, e.Code))
+		}
+	}
+	if len(details) == 0 {
+		return "validation failed"
+	}
+	return "validation failed: " + strings.Join(details, ", ")
+}
@@ -0,0 +1,120 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	commit := target{owner: "some-owner", repository: "some-repo", sha: "abc123"}
+
+	cases := []struct {
+		name            string
+		err             error
+		expectedKind    error
+		expectedMessage string
+	}{
+		{
+			name:            "unauthorized",
+			err:             newErrorResponse(http.StatusUnauthorized, "Bad credentials"),
+			expectedKind:    errUnauthorized,
+			expectedMessage: "token is invalid or has expired",
+		},
+		{
+			name:            "forbidden",
+			err:             newErrorResponse(http.StatusForbidden, "Resource not accessible by integration"),
+			expectedKind:    errForbidden,
+			expectedMessage: "token lacks repo:status on some-owner/some-repo",
+		},
+		{
+			name:            "not_found",
+			err:             newErrorResponse(http.StatusNotFound, "Not Found"),
+			expectedKind:    errNotFound,
+			expectedMessage: "repository some-owner/some-repo not found or the token has no access to it",
+		},
+		{
+			name:            "unknown_sha",
+			err:             newErrorResponse(http.StatusUnprocessableEntity, "No commit found for SHA: abc123"),
+			expectedKind:    errInvalid,
+			expectedMessage: "SHA abc123 not found in repository some-owner/some-repo",
+		},
+		{
+			name: "validation_failed",
+			err: &github.ErrorResponse{
+				Response: &http.Response{StatusCode: http.StatusUnprocessableEntity, Request: &http.Request{}},
+				Message:  "Validation Failed",
+				Errors:   []github.Error{{Resource: "Status", Field: "description", Code: "custom", Message: "description is too long (maximum is 140 characters)"}},
+			},
+			expectedKind:    errInvalid,
+			expectedMessage: "validation failed: description is too long (maximum is 140 characters)",
+		},
+		{
+			name: "server_error_is_transient",
+			err:  newErrorResponse(http.StatusBadGateway, "Bad Gateway"),
+		},
+		{
+			name: "network_error_is_transient",
+			err:  errors.New("connection reset by peer"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			apiErr := classifyError(c.err, commit, permissionStatuses)
+			if c.expectedKind == nil {
+				require.Nil(t, apiErr)
+				return
+			}
+			require.NotNil(t, apiErr)
+			require.ErrorIs(t, apiErr, c.expectedKind)
+			require.ErrorIs(t, apiErr, c.err)
+			require.Equal(t, c.expectedMessage, apiErr.message)
+		})
+	}
+}
+
+func TestWithRetryStopsOnPermanentErrors(t *testing.T) {
+	cases := []struct {
+		name          string
+		err           error
+		expectedCalls int
+	}{
+		{
+			name:          "permanent_error_is_not_retried",
+			err:           newErrorResponse(http.StatusNotFound, "Not Found"),
+			expectedCalls: 1,
+		},
+		{
+			name:          "transient_error_is_retried",
+			err:           newErrorResponse(http.StatusInternalServerError, "Server Error"),
+			expectedCalls: 2,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gh := ghClient{maxConnectionRetries: uint64(1)}
+			calls := 0
+			err := gh.withRetry(context.Background(), target{}, permissionStatuses, func(context.Context) (*github.Response, error) {
+				calls++
+				return nil, c.err
+			})
+			require.ErrorIs(t, err, c.err)
+			require.Equal(t, c.expectedCalls, calls)
+		})
+	}
+}
+
+// newErrorResponse creates a GitHub error response with the status code and message.
+func newErrorResponse(statusCode int, message string) *github.ErrorResponse {
+	return &github.ErrorResponse{
+		Response: &http.Response{StatusCode: statusCode, Request: &http.Request{}},
+		Message:  message,
+	}
+}
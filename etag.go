@@ -0,0 +1,92 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// etagTransport makes GET requests conditional on the ETag of the last response for the same URL. GitHub answers
+// with 304 Not Modified when nothing changed, which does not count against the rate limit, and the cached response is
+// returned in its place so polling the same resource stays cheap.
+type etagTransport struct {
+	base  http.RoundTripper
+	mu    sync.Mutex
+	cache map[string]cachedResponse
+}
+
+// cachedResponse is a response body kept with the ETag it was returned with.
+type cachedResponse struct {
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	t.mu.Lock()
+	cached, ok := t.cache[key]
+	t.mu.Unlock()
+	if ok {
+		req = cloneRequest(req)
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		// Keep the rate limit headers of the new response
+		header := cached.header.Clone()
+		for k, v := range resp.Header {
+			header[k] = v
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(cached.body)),
+			ContentLength: int64(len(cached.body)),
+			Request:       req,
+		}, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.mu.Lock()
+	if t.cache == nil {
+		t.cache = map[string]cachedResponse{}
+	}
+	t.cache[key] = cachedResponse{etag: etag, header: resp.Header.Clone(), body: body}
+	t.mu.Unlock()
+	return resp, nil
+}
+
+// cloneRequest copies a request so a RoundTripper can add headers to it, since it must not modify the request it was
+// given.
+func cloneRequest(req *http.Request) *http.Request {
+	return req.Clone(req.Context())
+}
@@ -0,0 +1,78 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// The values of sha_source. event picks the commit the event is about, github_sha always uses GITHUB_SHA.
+const (
+	shaSourceEvent     = "event"
+	shaSourceGitHubSHA = "github_sha"
+)
+
+// eventPayload holds the fields of the webhook event payloads that name a commit.
+type eventPayload struct {
+	After       string `json:"after"`
+	PullRequest struct {
+		Head struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	} `json:"pull_request"`
+	MergeGroup struct {
+		HeadSHA string `json:"head_sha"`
+	} `json:"merge_group"`
+	WorkflowRun struct {
+		HeadSHA string `json:"head_sha"`
+	} `json:"workflow_run"`
+	CheckSuite struct {
+		HeadSHA string `json:"head_sha"`
+	} `json:"check_suite"`
+	CheckRun struct {
+		HeadSHA string `json:"head_sha"`
+	} `json:"check_run"`
+}
+
+// eventSHA returns the commit the event is about from the event payload. GITHUB_SHA is not always that commit, for
+// pull requests it is the merge commit GitHub creates to test the merge, which nobody sees statuses on. An empty SHA is
+// returned for events without a commit of their own and when there is no payload, such as when running locally.
+func eventSHA(eventName, eventPath string) (string, error) {
+	if eventName == "" || eventPath == "" {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(eventPath)
+	if err != nil {
+		return "", fmt.Errorf("unable to read event payload: %w", err)
+	}
+	var payload eventPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return "", fmt.Errorf("unable to parse event payload: %w", err)
+	}
+
+	switch eventName {
+	case "pull_request", "pull_request_target", "pull_request_review", "pull_request_review_comment":
+		return payload.PullRequest.Head.SHA, nil
+	case "merge_group":
+		return payload.MergeGroup.HeadSHA, nil
+	case "workflow_run":
+		return payload.WorkflowRun.HeadSHA, nil
+	case "check_suite":
+		return payload.CheckSuite.HeadSHA, nil
+	case "check_run":
+		return payload.CheckRun.HeadSHA, nil
+	case "push":
+		// Deleting a branch pushes the zero SHA
+		if strings.Trim(payload.After, "0") == "" {
+			return "", nil
+		}
+		return payload.After, nil
+	default:
+		return "", nil
+	}
+}
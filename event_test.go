@@ -0,0 +1,110 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventSHA(t *testing.T) {
+	cases := []struct {
+		name        string
+		eventName   string
+		payload     string
+		expected    string
+		expectError string
+	}{
+		{
+			name:      "pull_request",
+			eventName: "pull_request",
+			payload:   `{"pull_request":{"head":{"sha":"head-sha"},"merge_commit_sha":"merge-sha"}}`,
+			expected:  "head-sha",
+		},
+		{
+			name:      "pull_request_target",
+			eventName: "pull_request_target",
+			payload:   `{"pull_request":{"head":{"sha":"head-sha"}}}`,
+			expected:  "head-sha",
+		},
+		{
+			name:      "merge_group",
+			eventName: "merge_group",
+			payload:   `{"merge_group":{"head_sha":"group-sha","base_sha":"base-sha"}}`,
+			expected:  "group-sha",
+		},
+		{
+			name:      "workflow_run",
+			eventName: "workflow_run",
+			payload:   `{"workflow_run":{"head_sha":"run-sha"}}`,
+			expected:  "run-sha",
+		},
+		{
+			name:      "check_suite",
+			eventName: "check_suite",
+			payload:   `{"check_suite":{"head_sha":"suite-sha"}}`,
+			expected:  "suite-sha",
+		},
+		{
+			name:      "push",
+			eventName: "push",
+			payload:   `{"before":"before-sha","after":"after-sha"}`,
+			expected:  "after-sha",
+		},
+		{
+			name:      "push_deleting_branch",
+			eventName: "push",
+			payload:   `{"after":"0000000000000000000000000000000000000000"}`,
+			expected:  "",
+		},
+		{
+			name:      "other_event",
+			eventName: "workflow_dispatch",
+			payload:   `{"ref":"refs/heads/main"}`,
+			expected:  "",
+		},
+		{
+			name:        "error_invalid_payload",
+			eventName:   "push",
+			payload:     `{"after":`,
+			expectError: "unable to parse event payload",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			eventPath := filepath.Join(t.TempDir(), "event.json")
+			require.NoError(t, os.WriteFile(eventPath, []byte(c.payload), 0o600))
+
+			got, err := eventSHA(c.eventName, eventPath)
+			if c.expectError != "" {
+				require.ErrorContains(t, err, c.expectError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, got)
+		})
+	}
+}
+
+func TestGetSHASource(t *testing.T) {
+	eventPath := filepath.Join(t.TempDir(), "event.json")
+	require.NoError(t, os.WriteFile(eventPath, []byte(`{"pull_request":{"head":{"sha":"head-sha"}}}`), 0o600))
+	t.Setenv("GITHUB_EVENT_NAME", "pull_request")
+	t.Setenv("GITHUB_EVENT_PATH", eventPath)
+	t.Setenv("GITHUB_SHA", "merge-sha")
+
+	got, err := getSHA("")
+	require.NoError(t, err)
+	require.Equal(t, "head-sha", got)
+
+	got, err = getSHA(shaSourceGitHubSHA)
+	require.NoError(t, err)
+	require.Equal(t, "merge-sha", got)
+
+	_, err = getSHA("foo")
+	require.EqualError(t, err, "sha_source value not supported: foo")
+}
@@ -0,0 +1,309 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// runActionErr is returned when the action is given the run input. Its image only has the action binary, so there is
+// no command it could run.
+const runActionErr = "run is only supported by the CLI, pass the command after -- to commit-status-action set"
+
+// outputTailLines is the number of lines of command output kept for the summary.
+const outputTailLines = 20
+
+// commandResult is the outcome of running the wrapped command.
+type commandResult struct {
+	exitCode int
+	duration time.Duration
+	tail     []string
+	err      error
+}
+
+// wrapCommand posts a pending status, runs the command and then posts success or failure depending on its exit code.
+// The final status has the duration in its description and, for check runs, the last lines of output in its text. The
+// command runs even when the pending status could not be posted, that error is returned with the one of the final
+// status.
+func (gh *ghClient) wrapCommand(ctx context.Context, stdout, stderr io.Writer) (commandResult, []statusResult, error) {
+	in := gh.input
+
+	var err error
+	gh.input, err = in.withState("pending", 0, nil)
+	if err != nil {
+		return commandResult{}, nil, err
+	}
+	results, pendingErr := gh.publish(ctx)
+	if pendingErr != nil {
+		action.Errorf("Unable to post the pending status, running the command anyway: %s", pendingErr)
+	}
+	// Update the check run that was just created rather than creating a second one
+	if in.mode == modeCheckRun && len(results) > 0 && results[0].err == nil {
+		in.checkRunID = results[0].id
+	}
+
+	res := runCommand(ctx, in.command, stdout, stderr)
+
+	state := "success"
+	if res.exitCode != 0 {
+		state = "failure"
+	}
+	gh.input, err = in.withState(state, res.duration, func(description string) string {
+		return commandDescription(description, res)
+	})
+	if err != nil {
+		return res, nil, err
+	}
+	if in.mode == modeCheckRun && len(res.tail) > 0 {
+		gh.input.text = strings.TrimSpace(in.text + "\n\n" + outputBlock(res.tail))
+	}
+	results, err = gh.publish(ctx)
+
+	errs := multierror.Append(pendingErr, err)
+	if errs.ErrorOrNil() != nil {
+		errs.ErrorFormat = joinErrors
+		return res, results, errs
+	}
+	return res, results, nil
+}
+
+// runCommand runs the command, streaming its output and keeping the last lines of it.
+func runCommand(ctx context.Context, command []string, stdout, stderr io.Writer) commandResult {
+	tail := &tailWriter{max: outputTailLines}
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout = io.MultiWriter(stdout, tail)
+	cmd.Stderr = io.MultiWriter(stderr, tail)
+
+	start := time.Now()
+	err := cmd.Run()
+	res := commandResult{
+		duration: time.Since(start),
+		tail:     tail.lines(),
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		res.exitCode = exitErr.ExitCode()
+	default:
+		// The command could not be started at all
+		res.exitCode = 1
+		res.err = err
+		res.tail = append(res.tail, err.Error())
+	}
+	return res
+}
+
+// commandDescription describes how the command finished, after the description from the inputs when there is one.
+func commandDescription(description string, res commandResult) string {
+	var outcome string
+	switch {
+	case res.err != nil:
+		outcome = fmt.Sprintf("failed to start: %s", res.err)
+	case res.exitCode != 0:
+		outcome = fmt.Sprintf("failed with exit code %d in %s", res.exitCode, formatDuration(res.duration))
+	default:
+		outcome = fmt.Sprintf("succeeded in %s", formatDuration(res.duration))
+	}
+	if description == "" {
+		return strings.ToUpper(outcome[:1]) + outcome[1:]
+	}
+	return fmt.Sprintf("%s: %s", description, outcome)
+}
+
+// commandSummary renders the last lines of output for the job summary.
+func commandSummary(command []string, res commandResult) string {
+	return fmt.Sprintf("#### Output of `%s`\n\n%s\n", strings.Join(command, " "), outputBlock(res.tail))
+}
+
+// outputBlock wraps lines of output in a Markdown code block.
+func outputBlock(lines []string) string {
+	return "```\n" + strings.Join(lines, "\n") + "\n```"
+}
+
+// formatDuration rounds a duration so it reads well in a description.
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+// withState returns a copy of the inputs with the state set on every status and the templates rendered again for it.
+// The descriptions are passed through describe when it is set.
+func (in input) withState(state string, elapsed time.Duration, describe func(string) string) (input, error) {
+	// The states set by the wrapper and the lifecycle phases are always valid
+	in.state, _ = in.convertState(state)
+	if in.mode == modeCheckRun {
+		in.checkStatus, in.conclusion, _ = convertActionStateToCheckRunStatus(state, in.stateMap)
+	}
+
+	statuses := make([]statusEntry, len(in.statuses))
+	for i, entry := range in.statuses {
+		entry.State = in.state
+		statuses[i] = entry
+	}
+	if len(statuses) > 0 {
+		in.statuses = statuses
+	}
+
+	in.elapsed = elapsed
+	in.describe = describe
+	in, err := in.renderTemplates()
+	if err != nil {
+		return input{}, err
+	}
+	return in.describeStatuses(), nil
+}
+
+// describeStatuses passes the descriptions through describe, when it is set, and truncates them.
+func (in input) describeStatuses() input {
+	if in.describe != nil {
+		in.description = in.describe(in.description)
+		for i := range in.statuses {
+			in.statuses[i].Description = in.describe(in.statuses[i].Description)
+		}
+	}
+
+	// The inputs were already validated, so a description can only be too long because of what was added to it here
+	if in.mode != modeCheckRun {
+		in.description = truncateDescription(in.description)
+	}
+	for i := range in.statuses {
+		in.statuses[i].Description = truncateDescription(in.statuses[i].Description)
+	}
+	return in
+}
+
+// commandArgs returns the command after `--` in the arguments, if there is one.
+func commandArgs(args []string) []string {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[i+1:]
+		}
+	}
+	return nil
+}
+
+// withRunInput returns a getInputFunc that reads the command from the arguments instead of the run input.
+func withRunInput(getInput getInputFunc, command []string) getInputFunc {
+	run := quoteCommand(command)
+	return func(name string) string {
+		if name == "run" {
+			return run
+		}
+		return getInput(name)
+	}
+}
+
+// quoteCommand joins the arguments into a command that splitCommand splits back into the same arguments.
+func quoteCommand(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
+
+// splitCommand splits a command into arguments the way a shell would, honoring single quotes, double quotes and
+// backslash escapes. Other shell features like pipes and variables are not supported because the command is run
+// directly rather than through a shell, which the action image does not have.
+func splitCommand(command string) ([]string, error) {
+	var args []string
+	var current bytes.Buffer
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range command {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("run has an unterminated quote or escape: %s", command)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// tailWriter keeps the last lines written to it. It is safe to write to from stdout and stderr at the same time.
+type tailWriter struct {
+	mu      sync.Mutex
+	max     int
+	buf     []string
+	partial []byte
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data := append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		w.buf = append(w.buf, strings.TrimRight(string(data[:i]), "\r"))
+		if len(w.buf) > w.max {
+			w.buf = w.buf[len(w.buf)-w.max:]
+		}
+		data = data[i+1:]
+	}
+	w.partial = append([]byte{}, data...)
+	return len(p), nil
+}
+
+// lines returns the last lines, including a final line without a newline.
+func (w *tailWriter) lines() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	lines := append([]string{}, w.buf...)
+	if len(w.partial) > 0 {
+		lines = append(lines, string(w.partial))
+	}
+	if len(lines) > w.max {
+		lines = lines[len(lines)-w.max:]
+	}
+	return lines
+}
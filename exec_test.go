@@ -0,0 +1,162 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCommand(t *testing.T) {
+	cases := []struct {
+		name        string
+		command     string
+		expected    []string
+		expectError string
+	}{
+		{
+			name:     "words",
+			command:  "go test  ./...",
+			expected: []string{"go", "test", "./..."},
+		},
+		{
+			name:     "quotes_and_escapes",
+			command:  `sh -c 'echo "hi there"' "a \"b\"" c\ d ''`,
+			expected: []string{"sh", "-c", `echo "hi there"`, `a "b"`, "c d", ""},
+		},
+		{
+			name:        "error_unterminated_quote",
+			command:     `echo "hi`,
+			expectError: "unterminated quote",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := splitCommand(c.command)
+			if c.expectError != "" {
+				require.ErrorContains(t, err, c.expectError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, got)
+		})
+	}
+}
+
+func TestQuoteCommandRoundTrip(t *testing.T) {
+	args := []string{"go", "test", "-run", "Test'Foo\"", "", `back\slash`, "two words"}
+	got, err := splitCommand(quoteCommand(args))
+	require.NoError(t, err)
+	require.Equal(t, args, got)
+}
+
+func TestCommandArgs(t *testing.T) {
+	require.Nil(t, commandArgs([]string{"foo"}))
+	require.Equal(t, []string{"go", "test", "./..."}, commandArgs([]string{"--", "go", "test", "./..."}))
+}
+
+func TestTailWriter(t *testing.T) {
+	w := &tailWriter{max: 2}
+	_, err := w.Write([]byte("one\ntwo\nth"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("ree\r\nfour"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"three", "four"}, w.lines())
+}
+
+func TestCommandDescription(t *testing.T) {
+	require.Equal(t, "Succeeded in 2s", commandDescription("", commandResult{duration: 2 * time.Second}))
+	require.Equal(t, "Unit tests: failed with exit code 3 in 1m5s", commandDescription("Unit tests", commandResult{exitCode: 3, duration: 65 * time.Second}))
+	require.Equal(t, "Failed to start: some-error", commandDescription("", commandResult{exitCode: 1, err: errors.New("some-error")}))
+}
+
+func TestRunCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	res := runCommand(context.Background(), []string{"sh", "-c", "echo out; echo err >&2; exit 3"}, &stdout, &stderr)
+	require.Equal(t, 3, res.exitCode)
+	require.NoError(t, res.err)
+	require.Equal(t, "out\n", stdout.String())
+	require.Equal(t, "err\n", stderr.String())
+	require.ElementsMatch(t, []string{"out", "err"}, res.tail)
+
+	res = runCommand(context.Background(), []string{"does-not-exist-command"}, &stdout, &stderr)
+	require.Equal(t, 1, res.exitCode)
+	require.Error(t, res.err)
+}
+
+func TestWrapCommand(t *testing.T) {
+	cases := []struct {
+		name             string
+		command          []string
+		expectedExitCode int
+		expectedStates   []string
+		expectedPrefix   string
+	}{
+		{
+			name:             "success",
+			command:          []string{"sh", "-c", "exit 0"},
+			expectedExitCode: 0,
+			expectedStates:   []string{"pending", "success"},
+			expectedPrefix:   "Succeeded in",
+		},
+		{
+			name:             "failure",
+			command:          []string{"sh", "-c", "exit 2"},
+			expectedExitCode: 2,
+			expectedStates:   []string{"pending", "failure"},
+			expectedPrefix:   "Failed with exit code 2 in",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mock := &mockStatusRecorder{}
+			gh := ghClient{
+				client: mock,
+				input: input{
+					context:    "some-context",
+					owner:      "some-owner",
+					repository: "some-repo",
+					sha:        "some-sha",
+					command:    c.command,
+				},
+			}
+
+			var out bytes.Buffer
+			res, results, err := gh.wrapCommand(context.Background(), &out, &out)
+			require.NoError(t, err)
+			require.Equal(t, c.expectedExitCode, res.exitCode)
+			require.Len(t, results, 1)
+			require.Equal(t, c.expectedStates, mock.states)
+			require.Equal(t, "", mock.descriptions[0])
+			require.True(t, strings.HasPrefix(mock.descriptions[1], c.expectedPrefix), mock.descriptions[1])
+		})
+	}
+}
+
+func TestWrapCommandRunsWhenPendingFails(t *testing.T) {
+	mock := &mockStatusRecorder{failStates: map[string]bool{"pending": true}}
+	gh := ghClient{
+		client: mock,
+		input: input{
+			context:    "some-context",
+			owner:      "some-owner",
+			repository: "some-repo",
+			sha:        "some-sha",
+			command:    []string{"sh", "-c", "exit 0"},
+		},
+	}
+
+	var out bytes.Buffer
+	res, results, err := gh.wrapCommand(context.Background(), &out, &out)
+	require.EqualError(t, err, "some-context on some-owner/some-repo@some-sha: some-error")
+	require.Equal(t, 0, res.exitCode)
+	require.Len(t, results, 1)
+	require.Equal(t, []string{"pending", "success"}, mock.states)
+}
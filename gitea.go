@@ -0,0 +1,125 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v53/github"
+)
+
+// giteaAPIPath is where Gitea and Forgejo serve their API on the instance.
+const giteaAPIPath = "/api/v1"
+
+// permissionGitea is the scope a Gitea or Forgejo token needs to post commit statuses.
+const permissionGitea = "write:repository"
+
+// giteaStates are the states Gitea has on top of the repo status states. Neutral conclusions are a warning rather than
+// a success there.
+var giteaStates = map[string]string{
+	"warning": "warning",
+	"neutral": "warning",
+}
+
+// giteaClient posts commit statuses to the GitHub like /api/v1/repos/{owner}/{repo}/statuses/{sha} endpoint of Gitea
+// and Forgejo.
+type giteaClient struct {
+	client *http.Client
+	apiURL string
+}
+
+// giteaStatus is a Gitea commit status, both the request and the response.
+type giteaStatus struct {
+	ID          int64      `json:"id,omitempty"`
+	State       string     `json:"state,omitempty"`
+	Status      string     `json:"status,omitempty"`
+	Context     string     `json:"context,omitempty"`
+	TargetURL   string     `json:"target_url,omitempty"`
+	Description string     `json:"description,omitempty"`
+	URL         string     `json:"url,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+}
+
+// newGiteaClient creates a client for the Gitea API at api_url that authenticates with the token.
+func newGiteaClient(in input) *giteaClient {
+	return &giteaClient{
+		client: &http.Client{Transport: &authTransport{base: http.DefaultTransport, header: "Authorization", value: "token " + in.token}},
+		apiURL: getGiteaAPIURL(in.apiURL),
+	}
+}
+
+// getGiteaAPIURL returns the Gitea API URL. The base URL of the instance is accepted as well, the API is then the
+// /api/v1 path on it.
+func getGiteaAPIURL(apiURL string) string {
+	apiURL = strings.TrimSuffix(apiURL, "/")
+	if strings.HasSuffix(apiURL, giteaAPIPath) {
+		return apiURL
+	}
+	return apiURL + giteaAPIPath
+}
+
+// giteaServerURL derives the Gitea web URL from its API URL, which is served from /api/v1 on the same host.
+func giteaServerURL(apiURL string) string {
+	return strings.TrimSuffix(apiURL, giteaAPIPath)
+}
+
+func (c *giteaClient) CreateStatus(ctx context.Context, owner, repo, ref string, status *github.RepoStatus) (*github.RepoStatus, *github.Response, error) {
+	var created giteaStatus
+	resp, err := doJSON(ctx, c.client, c.apiURL, c.statusRequest(owner, repo, ref, status), &created)
+	if err != nil {
+		return nil, resp, err
+	}
+	return created.repoStatus(), resp, nil
+}
+
+// statusRequest returns the request that creates the status.
+func (c *giteaClient) statusRequest(owner, repo, ref string, status *github.RepoStatus) dryRunRequest {
+	return dryRunRequest{
+		Method:     http.MethodPost,
+		Path:       fmt.Sprintf("repos/%s/%s/statuses/%s", url.PathEscape(owner), url.PathEscape(repo), url.PathEscape(ref)),
+		Owner:      owner,
+		Repository: repo,
+		SHA:        ref,
+		Body: giteaStatus{
+			State:       status.GetState(),
+			Context:     status.GetContext(),
+			TargetURL:   status.GetTargetURL(),
+			Description: status.GetDescription(),
+		},
+	}
+}
+
+// repoStatus converts the Gitea status to a GitHub one.
+func (s giteaStatus) repoStatus() *github.RepoStatus {
+	status := &github.RepoStatus{
+		ID:          github.Int64(s.ID),
+		URL:         github.String(s.URL),
+		State:       github.String(s.Status),
+		Context:     github.String(s.Context),
+		Description: github.String(s.Description),
+		TargetURL:   github.String(s.TargetURL),
+	}
+	if s.CreatedAt != nil {
+		status.CreatedAt = &github.Timestamp{Time: *s.CreatedAt}
+	}
+	return status
+}
+
+// convertActionStateToGiteaState validates that the state is a correct value and converts it to a Gitea commit status
+// state, ignoring case. Gitea has the repo status states and warning, so only warning and neutral differ from GitHub.
+// The mappings from state_map come first.
+func convertActionStateToGiteaState(actionState string, stateMap map[string]string) (string, error) {
+	key := strings.ToLower(strings.TrimSpace(actionState))
+	if _, ok := stateMap[key]; !ok {
+		if state, ok := giteaStates[key]; ok {
+			return state, nil
+		}
+	}
+	return convertActionStateToRepoStatusState(actionState, stateMap)
+}
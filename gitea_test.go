@@ -0,0 +1,88 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertActionStateToGiteaState(t *testing.T) {
+	cases := []struct {
+		name        string
+		actual      string
+		stateMap    map[string]string
+		expected    string
+		expectError bool
+	}{
+		{name: "success", actual: "success", expected: "success"},
+		{name: "failure", actual: "failure", expected: "failure"},
+		{name: "warning", actual: "Warning", expected: "warning"},
+		{name: "neutral_is_warning", actual: "neutral", expected: "warning"},
+		{name: "cancelled_is_error", actual: "cancelled", expected: "error"},
+		{name: "state_map_first", actual: "neutral", stateMap: map[string]string{"neutral": "success"}, expected: "success"},
+		{name: "state_map_to_warning", actual: "skipped", stateMap: map[string]string{"skipped": "warning"}, expected: "warning"},
+		{name: "fail_with_invalid_state", actual: "foo", expectError: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			state, err := convertActionStateToGiteaState(c.actual, c.stateMap)
+			assert.Equal(t, c.expected, state)
+			assert.Equal(t, c.expectError, err != nil)
+		})
+	}
+}
+
+func TestGiteaCreateStatus(t *testing.T) {
+	server, request := newRecordingServer(t, http.StatusCreated, `{"id":24601,"status":"warning","context":"ci","description":"Flaky","target_url":"https://some-url","url":"https://gitea.example.com/api/v1/repos/foo/bar/statuses/abc"}`)
+
+	cases := []struct {
+		name   string
+		apiURL string
+	}{
+		{name: "api_url", apiURL: server.URL + "/api/v1/"},
+		{name: "instance_url", apiURL: server.URL},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := newGiteaClient(input{token: "some-token", apiURL: c.apiURL})
+			status, _, err := client.CreateStatus(context.Background(), "foo", "bar", "abc", &github.RepoStatus{
+				State:       github.String("warning"),
+				Context:     github.String("ci"),
+				Description: github.String("Flaky"),
+				TargetURL:   github.String("https://some-url"),
+			})
+			require.NoError(t, err)
+
+			r := request()
+			require.Equal(t, "/api/v1/repos/foo/bar/statuses/abc", r.path)
+			require.Equal(t, "token some-token", r.header.Get("Authorization"))
+			require.Equal(t, map[string]any{
+				"state":       "warning",
+				"context":     "ci",
+				"description": "Flaky",
+				"target_url":  "https://some-url",
+			}, r.body)
+
+			require.Equal(t, int64(24601), status.GetID())
+			require.Equal(t, "warning", status.GetState())
+			require.Equal(t, "https://gitea.example.com/api/v1/repos/foo/bar/statuses/abc", status.GetURL())
+		})
+	}
+}
+
+func TestGiteaCommitURL(t *testing.T) {
+	for _, apiURL := range []string{"https://gitea.example.com/api/v1", "https://gitea.example.com/"} {
+		t.Run(apiURL, func(t *testing.T) {
+			client := newGiteaClient(input{apiURL: apiURL})
+			gh := ghClient{input: input{provider: providerGitea}, serverURL: giteaServerURL(client.apiURL)}
+			require.Equal(t, "https://gitea.example.com/foo/bar/commit/abc", gh.commitURL(target{owner: "foo", repository: "bar", sha: "abc"}))
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v53/github"
+)
+
+const defaultGitLabAPIURL = "https://gitlab.com/api/v4"
+
+// permissionGitLabAPI is the scope a GitLab token needs to post commit statuses.
+const permissionGitLabAPI = "api"
+
+// gitlabStates maps states to the states of GitLab commit statuses. GitLab has running, canceled and skipped, which
+// GitHub does not, so those are kept instead of being mapped to pending or error. A state mapped by state_map is
+// converted from the state it maps to.
+var gitlabStates = map[string]string{
+	"pending":         "pending",
+	"queued":          "pending",
+	"waiting":         "pending",
+	"requested":       "pending",
+	"running":         "running",
+	"in_progress":     "running",
+	"success":         "success",
+	"neutral":         "success",
+	"failed":          "failed",
+	"failure":         "failed",
+	"error":           "failed",
+	"timed_out":       "failed",
+	"action_required": "failed",
+	"startup_failure": "failed",
+	"stale":           "failed",
+	"canceled":        "canceled",
+	"cancelled":       "canceled",
+	"cancel":          "canceled",
+	"skipped":         "skipped",
+}
+
+// gitlabClient posts commit statuses to GitLab's POST /projects/:id/statuses/:sha endpoint.
+type gitlabClient struct {
+	client     *http.Client
+	apiURL     string
+	pipelineID int64
+}
+
+// gitlabStatus is a GitLab commit status, both the request and the response.
+type gitlabStatus struct {
+	ID          int64      `json:"id,omitempty"`
+	State       string     `json:"state,omitempty"`
+	Status      string     `json:"status,omitempty"`
+	Name        string     `json:"name,omitempty"`
+	TargetURL   string     `json:"target_url,omitempty"`
+	Description string     `json:"description,omitempty"`
+	PipelineID  int64      `json:"pipeline_id,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+}
+
+// newGitLabClient creates a client for the GitLab API that authenticates with the token.
+func newGitLabClient(in input) *gitlabClient {
+	return &gitlabClient{
+		client:     &http.Client{Transport: &authTransport{base: http.DefaultTransport, header: "PRIVATE-TOKEN", value: in.token}},
+		apiURL:     getGitLabAPIURL(in.apiURL),
+		pipelineID: in.pipelineID,
+	}
+}
+
+// getGitLabAPIURL returns the GitLab API URL. GITHUB_API_URL is not used since it points at GitHub.
+func getGitLabAPIURL(apiURL string) string {
+	if apiURL != "" {
+		return strings.TrimSuffix(apiURL, "/")
+	}
+	return defaultGitLabAPIURL
+}
+
+// gitlabServerURL derives the GitLab web URL from its API URL, which is served from /api/v4 on the same host.
+func gitlabServerURL(apiURL string) string {
+	return strings.TrimSuffix(apiURL, "/api/v4")
+}
+
+func (c *gitlabClient) CreateStatus(ctx context.Context, owner, repo, ref string, status *github.RepoStatus) (*github.RepoStatus, *github.Response, error) {
+	var created gitlabStatus
+	resp, err := doJSON(ctx, c.client, c.apiURL, c.statusRequest(owner, repo, ref, status), &created)
+	if err != nil {
+		return nil, resp, err
+	}
+	return created.repoStatus(), resp, nil
+}
+
+// statusRequest returns the request that creates the status. The project is addressed by its URL encoded path.
+func (c *gitlabClient) statusRequest(owner, repo, ref string, status *github.RepoStatus) dryRunRequest {
+	return dryRunRequest{
+		Method:     http.MethodPost,
+		Path:       fmt.Sprintf("projects/%s/statuses/%s", url.PathEscape(owner+"/"+repo), url.PathEscape(ref)),
+		Owner:      owner,
+		Repository: repo,
+		SHA:        ref,
+		Body: gitlabStatus{
+			State:       status.GetState(),
+			Name:        status.GetContext(),
+			TargetURL:   status.GetTargetURL(),
+			Description: status.GetDescription(),
+			PipelineID:  c.pipelineID,
+		},
+	}
+}
+
+// repoStatus converts the GitLab status to a GitHub one.
+func (s gitlabStatus) repoStatus() *github.RepoStatus {
+	status := &github.RepoStatus{
+		ID:          github.Int64(s.ID),
+		State:       github.String(s.Status),
+		Context:     github.String(s.Name),
+		Description: github.String(s.Description),
+		TargetURL:   github.String(s.TargetURL),
+	}
+	if s.CreatedAt != nil {
+		status.CreatedAt = &github.Timestamp{Time: *s.CreatedAt}
+	}
+	return status
+}
+
+// convertActionStateToGitLabState validates that the state is a correct value and converts it to a GitLab commit
+// status state, ignoring case. The mappings from state_map come first.
+func convertActionStateToGitLabState(actionState string, stateMap map[string]string) (string, error) {
+	key := strings.ToLower(strings.TrimSpace(actionState))
+	if state, ok := stateMap[key]; ok {
+		key = state
+	}
+	if state, ok := gitlabStates[key]; ok {
+		return state, nil
+	}
+	return "", fmt.Errorf("state value not supported: %s", actionState)
+}
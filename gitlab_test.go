@@ -0,0 +1,105 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertActionStateToGitLabState(t *testing.T) {
+	cases := []struct {
+		name        string
+		actual      string
+		stateMap    map[string]string
+		expected    string
+		expectError bool
+	}{
+		{name: "success", actual: "success", expected: "success"},
+		{name: "failure_is_failed", actual: "failure", expected: "failed"},
+		{name: "error_is_failed", actual: "error", expected: "failed"},
+		{name: "pending", actual: "pending", expected: "pending"},
+		{name: "in_progress_is_running", actual: "in_progress", expected: "running"},
+		{name: "running", actual: "Running", expected: "running"},
+		{name: "cancelled_is_canceled", actual: "cancelled", expected: "canceled"},
+		{name: "skipped", actual: "skipped", expected: "skipped"},
+		{name: "state_map_first", actual: "skipped", stateMap: map[string]string{"skipped": "success"}, expected: "success"},
+		{name: "state_map_to_gitlab_state", actual: "cancelled", stateMap: map[string]string{"cancelled": "skipped"}, expected: "skipped"},
+		{name: "fail_with_invalid_state", actual: "foo", expectError: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			state, err := convertActionStateToGitLabState(c.actual, c.stateMap)
+			assert.Equal(t, c.expected, state)
+			assert.Equal(t, c.expectError, err != nil)
+		})
+	}
+}
+
+func TestGitLabCreateStatus(t *testing.T) {
+	server, request := newRecordingServer(t, http.StatusCreated, `{"id":24601,"status":"running","name":"ci","description":"Building","target_url":"https://some-url","created_at":"2023-06-01T12:00:00Z"}`)
+
+	client := newGitLabClient(input{token: "some-token", apiURL: server.URL + "/api/v4", pipelineID: 7})
+	status, _, err := client.CreateStatus(context.Background(), "group", "project", "abc", &github.RepoStatus{
+		State:       github.String("running"),
+		Context:     github.String("ci"),
+		Description: github.String("Building"),
+		TargetURL:   github.String("https://some-url"),
+	})
+	require.NoError(t, err)
+
+	r := request()
+	require.Equal(t, "/api/v4/projects/group%2Fproject/statuses/abc", r.path)
+	require.Equal(t, "some-token", r.header.Get("PRIVATE-TOKEN"))
+	require.Equal(t, map[string]any{
+		"state":       "running",
+		"name":        "ci",
+		"description": "Building",
+		"target_url":  "https://some-url",
+		"pipeline_id": float64(7),
+	}, r.body)
+
+	require.Equal(t, int64(24601), status.GetID())
+	require.Equal(t, "running", status.GetState())
+	require.Equal(t, "ci", status.GetContext())
+	require.Equal(t, time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC), status.GetCreatedAt().Time)
+}
+
+func TestGitLabErrorsAreClassified(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"404 Project Not Found"}`))
+	}))
+	defer server.Close()
+
+	in := input{
+		provider:   providerGitLab,
+		token:      "some-token",
+		apiURL:     server.URL + "/api/v4",
+		owner:      "group",
+		repository: "project",
+		sha:        "abc",
+		state:      "success",
+	}
+	gh := ghClient{client: newGitLabClient(in), input: in, maxConnectionRetries: uint64(3)}
+	_, err := gh.createStatus(context.Background())
+	require.True(t, errors.Is(err, errNotFound))
+	require.ErrorContains(t, err, "repository group/project not found")
+}
+
+func TestGitLabServerURL(t *testing.T) {
+	require.Equal(t, "https://gitlab.com", gitlabServerURL(getGitLabAPIURL("")))
+	require.Equal(t, "https://gitlab.example.com", gitlabServerURL(getGitLabAPIURL("https://gitlab.example.com/api/v4/")))
+
+	gh := ghClient{input: input{provider: providerGitLab}, serverURL: "https://gitlab.com"}
+	require.Equal(t, "https://gitlab.com/group/project/-/commit/abc", gh.commitURL(target{owner: "group", repository: "project", sha: "abc"}))
+}
@@ -0,0 +1,78 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+
+	"github.com/google/go-github/v53/github"
+)
+
+const idempotentModeErr = "idempotent is not supported with mode check-run"
+
+// maxStatusesPerPage is the largest page of statuses GitHub returns.
+const maxStatusesPerPage = 100
+
+// currentStatuses returns the latest status of every context on each target. A target whose statuses cannot be read
+// is left out, so its statuses are posted as if none were current.
+func (gh *ghClient) currentStatuses(ctx context.Context, targets []target) map[target]map[string]*github.RepoStatus {
+	current := map[target]map[string]*github.RepoStatus{}
+	for _, t := range targets {
+		if _, ok := current[t]; ok {
+			continue
+		}
+		statuses, err := gh.combinedStatus(ctx, t)
+		if err != nil {
+			action.Warningf("Unable to read the current statuses of %s, posting every status: %s", t, err)
+			continue
+		}
+		current[t] = statuses
+	}
+	return current
+}
+
+// combinedStatus reads the latest status of every context on the target.
+func (gh *ghClient) combinedStatus(ctx context.Context, t target) (map[string]*github.RepoStatus, error) {
+	statuses := map[string]*github.RepoStatus{}
+	opts := &github.ListOptions{PerPage: maxStatusesPerPage}
+	for {
+		var combined *github.CombinedStatus
+		var resp *github.Response
+		err := gh.withRetry(ctx, t, permissionStatuses, func(ctx context.Context) (*github.Response, error) {
+			var err error
+			combined, resp, err = gh.commits.GetCombinedStatus(ctx, t.owner, t.repository, t.sha, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, status := range combined.Statuses {
+			statuses[status.GetContext()] = status
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return statuses, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// isCurrent reports whether the status already has the state, description and details URL of the entry.
+func isCurrent(status *github.RepoStatus, entry statusEntry) bool {
+	return status != nil &&
+		status.GetState() == entry.State &&
+		status.GetDescription() == entry.Description &&
+		status.GetTargetURL() == entry.DetailsURL
+}
+
+// skippedResult is the result of a status that was not posted because the latest status is already the same.
+func skippedResult(job statusJob, status *github.RepoStatus) statusResult {
+	return statusResult{
+		target:    job.target,
+		entry:     job.entry,
+		id:        status.GetID(),
+		url:       status.GetURL(),
+		createdAt: status.GetCreatedAt().Time,
+		skipped:   true,
+	}
+}
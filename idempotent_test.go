@@ -0,0 +1,100 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsCurrent(t *testing.T) {
+	status := &github.RepoStatus{
+		Context:     github.String("unit"),
+		State:       github.String("success"),
+		Description: github.String("some-description"),
+		TargetURL:   github.String("https://some-url"),
+	}
+	entry := statusEntry{Context: "unit", State: "success", Description: "some-description", DetailsURL: "https://some-url"}
+
+	require.True(t, isCurrent(status, entry))
+	require.False(t, isCurrent(nil, entry))
+	entry.State = "failure"
+	require.False(t, isCurrent(status, entry))
+	entry.State = "success"
+	entry.Description = "other-description"
+	require.False(t, isCurrent(status, entry))
+}
+
+func TestCreateStatusIdempotent(t *testing.T) {
+	mock := &mockStatusRecorder{}
+	commits := &mockghCommitsClient{statuses: []*github.RepoStatus{
+		{ID: github.Int64(24601), Context: github.String("unit"), State: github.String("success"), Description: github.String("")},
+		{ID: github.Int64(24602), Context: github.String("lint"), State: github.String("pending"), Description: github.String("")},
+	}}
+	gh := ghClient{
+		client:  mock,
+		commits: commits,
+		input: input{
+			owner:      "some-owner",
+			repository: "some-repo",
+			sha:        "some-sha",
+			idempotent: true,
+			statuses: []statusEntry{
+				{Context: "unit", State: "success"},
+				{Context: "lint", State: "success"},
+				{Context: "e2e", State: "success"},
+			},
+		},
+	}
+
+	results, err := gh.createStatus(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"e2e", "lint"}, mock.contexts())
+	require.True(t, results[0].skipped)
+	require.Equal(t, int64(24601), results[0].id)
+	require.False(t, results[1].skipped)
+	require.False(t, results[2].skipped)
+}
+
+func TestCreateStatusIdempotentPostsWhenStatusesCannotBeRead(t *testing.T) {
+	mock := &mockStatusRecorder{}
+	gh := ghClient{
+		client:  mock,
+		commits: &mockghCommitsClient{err: errors.New("some-error")},
+		input: input{
+			owner:      "some-owner",
+			repository: "some-repo",
+			sha:        "some-sha",
+			state:      "success",
+			context:    "unit",
+			idempotent: true,
+		},
+	}
+
+	results, err := gh.createStatus(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"unit"}, mock.contexts())
+	require.False(t, results[0].skipped)
+}
+
+// mockghCommitsClient returns the statuses as the combined status of every ref.
+type mockghCommitsClient struct {
+	statuses []*github.RepoStatus
+	err      error
+}
+
+func (m *mockghCommitsClient) GetCommitSHA1(_ context.Context, _, _, ref, _ string) (string, *github.Response, error) {
+	return ref, nil, nil
+}
+
+func (m *mockghCommitsClient) GetCombinedStatus(_ context.Context, _, _, _ string, _ *github.ListOptions) (*github.CombinedStatus, *github.Response, error) {
+	if m.err != nil {
+		return nil, nil, m.err
+	}
+	return &github.CombinedStatus{Statuses: m.statuses}, &github.Response{}, nil
+}
@@ -0,0 +1,274 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v53/github"
+)
+
+const lifecycleRunErr = "run is not supported with lifecycle"
+
+// The phases of a Docker action. The pre and post phases only do something when lifecycle is enabled.
+const (
+	phaseMain = "main"
+	phasePre  = "pre"
+	phasePost = "post"
+)
+
+// The values the pre phase saves for the post phase.
+const (
+	stateContext    = "context"
+	stateSHA        = "sha"
+	stateTargets    = "targets"
+	stateCheckRunID = "check_run_id"
+	stateStartedAt  = "started_at"
+)
+
+type ghWorkflowsClient interface {
+	ListWorkflowJobs(context.Context, string, string, int64, *github.ListWorkflowJobsOptions) (*github.Jobs, *github.Response, error)
+}
+
+// getPhase returns the phase to run. The image has the binary under a different name for each entrypoint, so the name
+// it was started as tells the phases apart. When running locally the phase can be passed with --phase instead.
+func getPhase(args []string) (string, error) {
+	phase := phaseMain
+	if len(args) > 0 {
+		name := filepath.Base(args[0])
+		switch {
+		case strings.HasSuffix(name, "-"+phasePre):
+			phase = phasePre
+		case strings.HasSuffix(name, "-"+phasePost):
+			phase = phasePost
+		}
+	}
+
+	for i := 1; i < len(args) && args[i] != "--"; i++ {
+		if strings.HasPrefix(args[i], "--phase=") {
+			phase = strings.TrimPrefix(args[i], "--phase=")
+			break
+		}
+		if args[i] == "--phase" && i+1 < len(args) {
+			phase = args[i+1]
+			break
+		}
+	}
+
+	switch phase {
+	case phaseMain, phasePre, phasePost:
+		return phase, nil
+	default:
+		return "", fmt.Errorf("phase value not supported: %s", phase)
+	}
+}
+
+// withoutFileInputs returns a getInputFunc that leaves out the inputs that name files. The pre phase runs before the
+// repository is checked out, so the files are only read by the post phase.
+func withoutFileInputs(getInput getInputFunc) getInputFunc {
+	return func(name string) string {
+		if name == "annotations_file" || name == "values_file" {
+			return ""
+		}
+		return getInput(name)
+	}
+}
+
+// startJob posts a pending status for the whole job and returns the values the post phase needs to finish it.
+func (gh *ghClient) startJob(ctx context.Context, now time.Time) ([]statusResult, map[string]string, error) {
+	var err error
+	gh.input, err = gh.input.withState("pending", 0, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	results, err := gh.publish(ctx)
+
+	state := map[string]string{}
+	for _, r := range results {
+		if r.err != nil {
+			continue
+		}
+		state[stateContext] = r.entry.Context
+		state[stateStartedAt] = now.UTC().Format(time.RFC3339Nano)
+		// A branch or pull request may move while the job runs, so the post phase finishes the commits that were
+		// resolved and marked pending rather than resolving the refs again
+		state[stateSHA] = gh.input.sha
+		if len(gh.input.targets) > 0 {
+			targets := make([]string, 0, len(gh.input.targets))
+			for _, t := range gh.input.targets {
+				targets = append(targets, t.String())
+			}
+			state[stateTargets] = strings.Join(targets, ",")
+		}
+		if gh.input.mode == modeCheckRun {
+			state[stateCheckRunID] = strconv.FormatInt(r.id, 10)
+		}
+		break
+	}
+	return results, state, err
+}
+
+// finishJob posts the final status for the job from the outcome of its steps to the commits the pre phase saved.
+// Nothing is posted when the pre phase did not post a pending status.
+func (gh *ghClient) finishJob(ctx context.Context, getState getInputFunc, now time.Time) ([]statusResult, error) {
+	statusContext := getState(stateContext)
+	if statusContext == "" {
+		action.Infof("No pending status was posted by the pre phase, nothing to finish")
+		return nil, nil
+	}
+
+	in := gh.input
+	if len(in.statuses) == 0 {
+		in.context = statusContext
+	}
+	if sha := getState(stateSHA); sha != "" {
+		in.sha = sha
+	}
+	if targets := getState(stateTargets); targets != "" {
+		var err error
+		in.targets, err = parseTargets(targets, in.provider == providerGitLab)
+		if err != nil {
+			return nil, fmt.Errorf("targets saved by the pre phase are not valid: %w", err)
+		}
+	}
+	if id := getState(stateCheckRunID); id != "" {
+		checkRunID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("check run ID saved by the pre phase is not valid: %s", id)
+		}
+		in.checkRunID = checkRunID
+	}
+
+	outcome, err := gh.jobOutcome(ctx)
+	// Without actions:read the outcome can never be known, which has to be fixed rather than posted as an error
+	if errors.Is(err, errForbidden) {
+		return nil, err
+	}
+	if err != nil {
+		// Leaving the status pending forever is worse than reporting an error
+		action.Warningf("Unable to determine the outcome of the job, posting error: %s", err)
+		outcome = "error"
+	}
+
+	var duration time.Duration
+	if startedAt, err := time.Parse(time.RFC3339Nano, getState(stateStartedAt)); err == nil {
+		duration = now.Sub(startedAt)
+	}
+	gh.input, err = in.withState(outcome, duration, func(description string) string {
+		return jobDescription(description, outcome, duration)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return gh.publish(ctx)
+}
+
+// jobOutcome looks up the steps of the running job through the Actions API and returns its outcome so far.
+func (gh *ghClient) jobOutcome(ctx context.Context) (string, error) {
+	runID, err := strconv.ParseInt(os.Getenv("GITHUB_RUN_ID"), 10, 64)
+	if err != nil {
+		return "", errors.New("GITHUB_RUN_ID environment variable not set")
+	}
+	repo, err := getRepository()
+	if err != nil {
+		return "", err
+	}
+	owner, repository, _ := strings.Cut(repo, "/")
+	t := target{owner: owner, repository: repository}
+
+	var jobs []*github.WorkflowJob
+	opts := &github.ListWorkflowJobsOptions{Filter: "latest", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		var page *github.Jobs
+		var resp *github.Response
+		err := gh.withRetry(ctx, t, permissionActions, func(ctx context.Context) (*github.Response, error) {
+			var err error
+			page, resp, err = gh.workflows.ListWorkflowJobs(ctx, owner, repository, runID, opts)
+			return resp, err
+		})
+		if err != nil {
+			return "", err
+		}
+		jobs = append(jobs, page.Jobs...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return runningJobOutcome(jobs, os.Getenv("RUNNER_NAME"))
+}
+
+// runningJobOutcome finds the job in progress on the runner and returns failure if one of its steps failed, cancelled
+// if one was cancelled and success otherwise.
+func runningJobOutcome(jobs []*github.WorkflowJob, runnerName string) (string, error) {
+	for _, job := range jobs {
+		if job.GetStatus() != "in_progress" || job.GetRunnerName() != runnerName {
+			continue
+		}
+
+		outcome := "success"
+		for _, step := range job.Steps {
+			switch step.GetConclusion() {
+			case "failure":
+				return "failure", nil
+			case "cancelled":
+				outcome = "cancelled"
+			}
+		}
+		return outcome, nil
+	}
+	return "", fmt.Errorf("no job in progress on runner %s", runnerName)
+}
+
+// jobDescription describes how the job finished, after the description from the inputs when there is one.
+func jobDescription(description, outcome string, duration time.Duration) string {
+	var verb string
+	switch outcome {
+	case "success":
+		verb = "succeeded"
+	case "failure":
+		verb = "failed"
+	case "cancelled":
+		verb = "was cancelled"
+	default:
+		verb = "ended with an error"
+	}
+	if duration > 0 {
+		verb = fmt.Sprintf("%s in %s", verb, formatDuration(duration))
+	}
+	if description == "" {
+		return "Job " + verb
+	}
+	return fmt.Sprintf("%s: job %s", description, verb)
+}
+
+// getState reads a value saved by the pre phase.
+func getState(name string) string {
+	return os.Getenv("STATE_" + name)
+}
+
+// saveState saves the values for the post phase in a stable order.
+func saveState(state map[string]string) {
+	if !hasCommandFile("GITHUB_STATE") {
+		return
+	}
+
+	keys := make([]string, 0, len(state))
+	for k := range state {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		action.SaveState(k, state[k])
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPhase(t *testing.T) {
+	cases := []struct {
+		name        string
+		args        []string
+		expected    string
+		expectError string
+	}{
+		{
+			name:     "main",
+			args:     []string{"/action"},
+			expected: phaseMain,
+		},
+		{
+			name:     "pre_entrypoint",
+			args:     []string{"/action-pre"},
+			expected: phasePre,
+		},
+		{
+			name:     "post_entrypoint",
+			args:     []string{"/action-post"},
+			expected: phasePost,
+		},
+		{
+			name:     "phase_argument",
+			args:     []string{"./bin/action", "--phase", "post"},
+			expected: phasePost,
+		},
+		{
+			name:     "phase_argument_with_equals",
+			args:     []string{"./bin/action", "--phase=pre"},
+			expected: phasePre,
+		},
+		{
+			name:     "phase_argument_of_command_is_ignored",
+			args:     []string{"./bin/action", "--", "foo", "--phase=pre"},
+			expected: phaseMain,
+		},
+		{
+			name:        "error_unknown_phase",
+			args:        []string{"./bin/action", "--phase=foo"},
+			expectError: "phase value not supported: foo",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := getPhase(c.args)
+			if c.expectError != "" {
+				require.EqualError(t, err, c.expectError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, got)
+		})
+	}
+}
+
+func TestWithoutFileInputs(t *testing.T) {
+	inputs := map[string]string{
+		"annotations_file": "lint.txt",
+		"values_file":      "values.json",
+		"context":          "some-context",
+	}
+	getInput := withoutFileInputs(func(name string) string { return inputs[name] })
+	require.Equal(t, "", getInput("annotations_file"))
+	require.Equal(t, "", getInput("values_file"))
+	require.Equal(t, "some-context", getInput("context"))
+}
+
+func TestRunningJobOutcome(t *testing.T) {
+	job := func(status, runner string, conclusions ...string) *github.WorkflowJob {
+		j := &github.WorkflowJob{Status: github.String(status), RunnerName: github.String(runner)}
+		for _, conclusion := range conclusions {
+			j.Steps = append(j.Steps, &github.TaskStep{Conclusion: github.String(conclusion)})
+		}
+		return j
+	}
+
+	cases := []struct {
+		name        string
+		jobs        []*github.WorkflowJob
+		expected    string
+		expectError string
+	}{
+		{
+			name:     "success",
+			jobs:     []*github.WorkflowJob{job("completed", "runner-1", "failure"), job("in_progress", "runner-2", "success", "skipped", "")},
+			expected: "success",
+		},
+		{
+			name:     "failure",
+			jobs:     []*github.WorkflowJob{job("in_progress", "runner-2", "success", "failure", "cancelled")},
+			expected: "failure",
+		},
+		{
+			name:     "cancelled",
+			jobs:     []*github.WorkflowJob{job("in_progress", "runner-2", "success", "cancelled")},
+			expected: "cancelled",
+		},
+		{
+			name:        "error_job_not_found",
+			jobs:        []*github.WorkflowJob{job("in_progress", "runner-1")},
+			expectError: "no job in progress on runner runner-2",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := runningJobOutcome(c.jobs, "runner-2")
+			if c.expectError != "" {
+				require.EqualError(t, err, c.expectError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, got)
+		})
+	}
+}
+
+func TestJobDescription(t *testing.T) {
+	require.Equal(t, "Job succeeded in 1m30s", jobDescription("", "success", 90*time.Second))
+	require.Equal(t, "Build: job was cancelled", jobDescription("Build", "cancelled", 0))
+	require.Equal(t, "Job ended with an error", jobDescription("", "error", 0))
+}
+
+func TestStartAndFinishJob(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ID", "42")
+	t.Setenv("GITHUB_REPOSITORY", "some-owner/some-repo")
+	t.Setenv("RUNNER_NAME", "some-runner")
+
+	mock := &mockStatusRecorder{}
+	workflows := &mockghWorkflowsClient{jobs: []*github.WorkflowJob{{
+		Status:     github.String("in_progress"),
+		RunnerName: github.String("some-runner"),
+		Steps:      []*github.TaskStep{{Conclusion: github.String("success")}, {Conclusion: github.String("cancelled")}},
+	}}}
+	in := input{
+		context:    "some-context",
+		owner:      "some-owner",
+		repository: "some-repo",
+		sha:        "some-sha",
+		lifecycle:  true,
+	}
+	start := time.Date(2023, 6, 1, 12, 0, 0, 900*int(time.Millisecond), time.UTC)
+
+	gh := ghClient{client: mock, workflows: workflows, input: in}
+	_, state, err := gh.startJob(context.Background(), start)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{stateContext: "some-context", stateSHA: "some-sha", stateStartedAt: "2023-06-01T12:00:00.9Z"}, state)
+
+	gh = ghClient{client: mock, workflows: workflows, input: in}
+	_, err = gh.finishJob(context.Background(), func(name string) string { return state[name] }, start.Add(643*time.Millisecond))
+	require.NoError(t, err)
+	require.Equal(t, int64(42), workflows.runID)
+	require.Equal(t, []string{"pending", "error"}, mock.states)
+	require.Equal(t, "Job was cancelled in 643ms", mock.descriptions[1])
+}
+
+func TestFinishJobUsesSavedCommits(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ID", "42")
+	t.Setenv("GITHUB_REPOSITORY", "some-owner/some-repo")
+	t.Setenv("RUNNER_NAME", "some-runner")
+
+	mock := &mockStatusRecorder{}
+	workflows := &mockghWorkflowsClient{jobs: []*github.WorkflowJob{{
+		Status:     github.String("in_progress"),
+		RunnerName: github.String("some-runner"),
+	}}}
+	in := input{
+		context:    "some-context",
+		owner:      "some-owner",
+		repository: "some-repo",
+		sha:        "resolved-sha",
+		targets: []target{
+			{owner: "some-owner", repository: "some-repo", sha: "resolved-sha"},
+			{owner: "other-owner", repository: "other-repo", sha: "other-sha"},
+		},
+		lifecycle: true,
+	}
+
+	gh := ghClient{client: mock, workflows: workflows, input: in}
+	_, state, err := gh.startJob(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Equal(t, "resolved-sha", state[stateSHA])
+	require.Equal(t, "some-owner/some-repo@resolved-sha,other-owner/other-repo@other-sha", state[stateTargets])
+
+	// The post phase has the refs from the inputs, which may point somewhere else by now
+	in.sha = "main"
+	in.targets = []target{
+		{owner: "some-owner", repository: "some-repo", sha: "main"},
+		{owner: "other-owner", repository: "other-repo", sha: "main"},
+	}
+	mock = &mockStatusRecorder{}
+	gh = ghClient{client: mock, workflows: workflows, input: in}
+	_, err = gh.finishJob(context.Background(), func(name string) string { return state[name] }, time.Now())
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"some-owner/some-repo@resolved-sha", "other-owner/other-repo@other-sha"}, mock.targets)
+	require.Equal(t, []string{"success", "success"}, mock.states)
+}
+
+func TestFinishJobWithoutActionsPermission(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ID", "42")
+	t.Setenv("GITHUB_REPOSITORY", "some-owner/some-repo")
+
+	mock := &mockStatusRecorder{}
+	workflows := &mockghWorkflowsClient{err: newErrorResponse(http.StatusForbidden, "Resource not accessible by integration")}
+	gh := ghClient{client: mock, workflows: workflows, input: input{lifecycle: true}}
+	state := map[string]string{stateContext: "some-context", stateSHA: "some-sha"}
+	_, err := gh.finishJob(context.Background(), func(name string) string { return state[name] }, time.Now())
+	require.ErrorIs(t, err, errForbidden)
+	require.ErrorContains(t, err, "token lacks actions:read on some-owner/some-repo")
+	require.Empty(t, mock.states)
+}
+
+func TestFinishJobWithoutPreState(t *testing.T) {
+	mock := &mockStatusRecorder{}
+	gh := ghClient{client: mock, input: input{lifecycle: true}}
+	results, err := gh.finishJob(context.Background(), func(string) string { return "" }, time.Now())
+	require.NoError(t, err)
+	require.Empty(t, results)
+	require.Empty(t, mock.states)
+}
+
+type mockghWorkflowsClient struct {
+	jobs  []*github.WorkflowJob
+	runID int64
+	err   error
+}
+
+func (m *mockghWorkflowsClient) ListWorkflowJobs(_ context.Context, _, _ string, runID int64, _ *github.ListWorkflowJobsOptions) (*github.Jobs, *github.Response, error) {
+	m.runID = runID
+	if m.err != nil {
+		return nil, nil, m.err
+	}
+	return &github.Jobs{Jobs: m.jobs}, &github.Response{}, nil
+}
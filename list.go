@@ -0,0 +1,116 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v53/github"
+)
+
+const getContextRequiredErr = "context is required with command get"
+const listStatusesErr = "statuses is not supported with command get or list"
+const listTargetsErr = "targets is not supported with command get or list"
+
+// statusListing is the latest status of the contexts that were read by get or list.
+type statusListing struct {
+	target   target
+	statuses []*github.RepoStatus
+}
+
+// listedStatus is a status written to the statuses output of get and list.
+type listedStatus struct {
+	Context     string `json:"context"`
+	State       string `json:"state"`
+	Description string `json:"description,omitempty"`
+	DetailsURL  string `json:"details_url,omitempty"`
+	CreatedAt   string `json:"created_at,omitempty"`
+}
+
+// listStatuses reads the latest status of the contexts on the commit. get reads the status of the context and fails
+// when it has none, list reads every context or the contexts that match the contexts patterns.
+func (gh *ghClient) listStatuses(ctx context.Context) (statusListing, error) {
+	t := gh.input.defaultTarget()
+	statuses, err := gh.combinedStatus(ctx, t)
+	if err != nil {
+		return statusListing{}, err
+	}
+
+	listing := statusListing{target: t}
+	for statusContext, status := range statuses {
+		if gh.input.subcommand == commandGet && statusContext != gh.input.context {
+			continue
+		}
+		if gh.input.subcommand == commandList && len(gh.input.contexts) > 0 && !matchesContext(statusContext, gh.input.contexts) {
+			continue
+		}
+		listing.statuses = append(listing.statuses, status)
+	}
+	sort.Slice(listing.statuses, func(i, j int) bool {
+		return listing.statuses[i].GetContext() < listing.statuses[j].GetContext()
+	})
+
+	if gh.input.subcommand == commandGet && len(listing.statuses) == 0 {
+		return statusListing{}, fmt.Errorf("%s has no status for %s", t, gh.input.context)
+	}
+	return listing, nil
+}
+
+// state returns the highest ranked state of the statuses, or pending when there are none.
+func (l statusListing) state() string {
+	if len(l.statuses) == 0 {
+		return "pending"
+	}
+	state := "success"
+	for _, status := range l.statuses {
+		if statePrecedence[status.GetState()] > statePrecedence[state] {
+			state = status.GetState()
+		}
+	}
+	return state
+}
+
+// list returns the statuses in the form they are written as JSON.
+func (l statusListing) list() []listedStatus {
+	list := make([]listedStatus, 0, len(l.statuses))
+	for _, status := range l.statuses {
+		list = append(list, listedStatus{
+			Context:     status.GetContext(),
+			State:       status.GetState(),
+			Description: status.GetDescription(),
+			DetailsURL:  status.GetTargetURL(),
+			CreatedAt:   formatOutputTime(status.GetCreatedAt().Time),
+		})
+	}
+	return list
+}
+
+// outputs returns the overall state and the state of every context as step outputs, the same way wait does.
+func (l statusListing) outputs() map[string]string {
+	states := map[string]string{}
+	for _, status := range l.statuses {
+		states[status.GetContext()] = status.GetState()
+	}
+	return map[string]string{
+		"state":    l.state(),
+		"statuses": marshalJSON(states),
+	}
+}
+
+// summary renders the statuses as a Markdown table for the job summary.
+func (l statusListing) summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Statuses of %s\n\n", l.target)
+	b.WriteString("| Context | State | Description |\n")
+	b.WriteString("| ------- | ----- | ----------- |\n")
+	for _, status := range l.statuses {
+		state := status.GetState()
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", escapeTableCell(status.GetContext()),
+			strings.TrimSpace(fmt.Sprintf("%s %s", stateEmoji[state], state)), escapeTableCell(status.GetDescription()))
+	}
+	return b.String()
+}
@@ -0,0 +1,123 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListStatuses(t *testing.T) {
+	statuses := []*github.RepoStatus{
+		newRepoStatus("deploy", "failure"),
+		newRepoStatus("ci/unit", "success"),
+		newRepoStatus("ci/lint", "pending"),
+	}
+
+	cases := []struct {
+		name             string
+		subcommand       string
+		context          string
+		contexts         []string
+		err              error
+		expectedContexts []string
+		expectedState    string
+		expectedErr      string
+	}{
+		{
+			name:             "list_every_context",
+			subcommand:       commandList,
+			expectedContexts: []string{"ci/lint", "ci/unit", "deploy"},
+			expectedState:    "failure",
+		},
+		{
+			name:             "list_matching_contexts",
+			subcommand:       commandList,
+			contexts:         []string{"ci/*"},
+			expectedContexts: []string{"ci/lint", "ci/unit"},
+			expectedState:    "pending",
+		},
+		{
+			name:             "list_without_matching_contexts",
+			subcommand:       commandList,
+			contexts:         []string{"release"},
+			expectedContexts: []string{},
+			expectedState:    "pending",
+		},
+		{
+			name:             "get_context",
+			subcommand:       commandGet,
+			context:          "ci/unit",
+			expectedContexts: []string{"ci/unit"},
+			expectedState:    "success",
+		},
+		{
+			name:        "get_context_without_status",
+			subcommand:  commandGet,
+			context:     "release",
+			expectedErr: "some-owner/some-repo@some-sha has no status for release",
+		},
+		{
+			name:        "statuses_cannot_be_read",
+			subcommand:  commandList,
+			err:         errors.New("some-error"),
+			expectedErr: "some-error",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gh := ghClient{
+				commits: &mockghCommitsClient{statuses: statuses, err: c.err},
+				input: input{
+					context:    c.context,
+					contexts:   c.contexts,
+					owner:      "some-owner",
+					repository: "some-repo",
+					sha:        "some-sha",
+					subcommand: c.subcommand,
+				},
+			}
+			listing, err := gh.listStatuses(context.Background())
+			if c.expectedErr != "" {
+				require.EqualError(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			contexts := []string{}
+			for _, s := range listing.list() {
+				contexts = append(contexts, s.Context)
+			}
+			require.Equal(t, c.expectedContexts, contexts)
+			require.Equal(t, c.expectedState, listing.state())
+		})
+	}
+}
+
+func TestStatusListingOutputs(t *testing.T) {
+	listing := statusListing{
+		target: target{owner: "foo", repository: "bar", sha: "abc"},
+		statuses: []*github.RepoStatus{
+			{
+				Context:     github.String("lint"),
+				State:       github.String("success"),
+				Description: github.String("Lint passed"),
+				TargetURL:   github.String("https://some-url"),
+			},
+		},
+	}
+
+	require.Equal(t, map[string]string{
+		"state":    "success",
+		"statuses": `{"lint":"success"}`,
+	}, listing.outputs())
+	require.Equal(t, "### Statuses of foo/bar@abc\n\n"+
+		"| Context | State | Description |\n"+
+		"| ------- | ----- | ----------- |\n"+
+		"| lint | ✅ success | Lint passed |\n", listing.summary())
+}
@@ -8,22 +8,31 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/v53/github"
 	"github.com/hashicorp/go-multierror"
 	actions "github.com/sethvargo/go-githubactions"
-	"github.com/sethvargo/go-retry"
 	"golang.org/x/oauth2"
 )
 
 const tokenRequiredErr = "token is a required field"
 const stateRequiredErr = "state is a required field"
+const privateKeyRequiredErr = "private_key is required with app_id"
 const ownerEnvNotSetErr = "GITHUB_OWNER environment variable not set"
 const repositoryEnvNotSetErr = "GITHUB_REPOSITORY environment variable not set"
 const shaEnvNotSetErr = "GITHUB_SHA environment variable not set"
 
+const annotationsModeErr = "annotations_file requires mode check-run"
+const statusesModeErr = "statuses is not supported with mode check-run"
+const targetsModeErr = "targets is not supported with mode check-run"
+
+const modeStatus = "status"
+const modeCheckRun = "check-run"
+
 type input struct {
 	token       string
 	state       string
@@ -33,29 +42,225 @@ type input struct {
 	repository  string
 	sha         string
 	detailsURL  string
+	// mode is either a commit status or a check run, empty is a commit status.
+	mode string
+	// title, summary and text are the output of a check run.
+	title   string
+	summary string
+	text    string
+	// checkRunID is the check run to update, zero creates a new one.
+	checkRunID int64
+	// checkStatus and conclusion are the state converted to a check run status and conclusion.
+	checkStatus string
+	conclusion  string
+	// annotations are parsed from annotations_file and added to the check run.
+	annotations []*github.CheckRunAnnotation
+	// statuses are posted instead of the single status of the inputs when they are set.
+	statuses []statusEntry
+	// targets are the commits the statuses are posted to, empty posts to the owner, repository and SHA of the inputs.
+	targets []target
+	// apiURL is the API of GitHub Enterprise Server or of another provider, empty uses GITHUB_API_URL or GitHub.
+	apiURL string
+	// appID and privateKey authenticate as a GitHub App instead of with the token.
+	appID      int64
+	privateKey string
+	// command is run between a pending and a final status when it is set.
+	command []string
+	// descriptionTemplate and detailsURLTemplate are the description and details URL before rendering.
+	descriptionTemplate string
+	detailsURLTemplate  string
+	// statusTemplates are the statuses before their descriptions and details URLs are rendered.
+	statusTemplates []statusEntry
+	// template renders the descriptions and details URLs as templates, otherwise they are used as they are.
+	template bool
+	// templateValues are the values from values_file that templates can use.
+	templateValues map[string]any
+	// elapsed is how long the wrapped command or the job took, zero before it finished.
+	elapsed time.Duration
+	// describe is applied to the rendered descriptions of the statuses set by the wrapper and the lifecycle phases.
+	describe func(string) string
+	// descriptionOverflow is what happens to descriptions over GitHub's limit, empty truncates them.
+	descriptionOverflow string
+	// shaSource picks where the SHA comes from when the sha input is not set.
+	shaSource string
+	// subcommand is what the action does, set posts statuses, get and list read them and wait waits for them. Empty is
+	// set.
+	subcommand string
+	// contexts are the contexts to wait for or the patterns of the contexts to roll up or list.
+	contexts []string
+	// waitTimeout and pollInterval control how long and how often wait polls, zero uses the defaults.
+	waitTimeout  time.Duration
+	pollInterval time.Duration
+	// stateMap overrides how states are converted to repo status states.
+	stateMap map[string]string
+	// dryRun prints the requests instead of creating statuses or check runs.
+	dryRun bool
+	// idempotent skips statuses that are already current instead of posting them again.
+	idempotent bool
+	// lifecycle posts pending in the pre phase and the job outcome in the post phase instead of posting in the main phase.
+	lifecycle bool
+	// installationID is the GitHub App installation, zero looks it up from the owner and repository.
+	installationID int64
+	// maxConcurrency is the number of statuses posted at the same time, zero uses the default.
+	maxConcurrency int
+	// maxRateLimitWait is the longest a retry waits for a rate limit to reset, zero uses the default.
+	maxRateLimitWait time.Duration
+	// provider is where the statuses are posted, empty is GitHub.
+	provider string
+	// pipelineID is the GitLab pipeline the statuses are added to, zero lets GitLab pick it.
+	pipelineID int64
+	// username sends the token to Bitbucket as an app password instead of a bearer token.
+	username string
 }
 
-type ghRepositoryClient interface {
-	CreateStatus(context.Context, string, string, string, *github.RepoStatus) (*github.RepoStatus, *github.Response, error)
+type ghChecksClient interface {
+	CreateCheckRun(context.Context, string, string, github.CreateCheckRunOptions) (*github.CheckRun, *github.Response, error)
+	UpdateCheckRun(context.Context, string, string, int64, github.UpdateCheckRunOptions) (*github.CheckRun, *github.Response, error)
 }
 
 type getInputFunc func(string) string
 
+// action reads the inputs and writes the logs, outputs and job summary. The CLI replaces it to log to stderr so that
+// stdout only has the result of the command.
+var action = actions.New()
+
 type ghClient struct {
-	client               ghRepositoryClient
+	// sleep waits for rate limits to reset, nil uses a timer.
+	sleep                func(context.Context, time.Duration) error
+	client               statusProvider
+	checks               ghChecksClient
+	workflows            ghWorkflowsClient
+	git                  ghGitClient
+	pulls                ghPullsClient
+	commits              ghCommitsClient
 	input                input
+	serverURL            string
 	maxConnectionRetries uint64
+	// dryRun records the requests instead of sending them when dry_run is set.
+	dryRun *dryRunClient
 }
 
 func main() {
 	ctx := context.Background()
-	client, err := newGHClient(ctx, uint64(5), actions.GetInput)
+	if len(os.Args) > 1 && isCLICommand(os.Args[1]) {
+		// Logs go to stderr so stdout only has the output of the command
+		action = actions.New(actions.WithWriter(os.Stderr))
+		os.Exit(runCLI(ctx, os.Args[1], os.Args[2:], action.GetInput, os.Stdout, os.Stderr))
+	}
+
+	// The command can only come from the arguments, the image has nothing to run
+	if action.GetInput("run") != "" {
+		action.Fatalf(runActionErr)
+	}
+	getInput := getInputFunc(action.GetInput)
+	if command := commandArgs(os.Args[1:]); len(command) > 0 {
+		getInput = withRunInput(getInput, command)
+	}
+
+	phase, err := getPhase(os.Args)
 	if err != nil {
-		actions.Fatalf(err.Error())
+		action.Fatalf(err.Error())
+	}
+	// The pre and post phases run for every use of the action, so they must not fail when lifecycle is not enabled
+	if lifecycle, _ := getBoolInput(getInput, "lifecycle"); phase != phaseMain && !lifecycle {
+		return
+	}
+	if phase == phasePre {
+		getInput = withoutFileInputs(getInput)
+	}
+
+	client, err := newGHClient(ctx, uint64(5), getInput)
+	if err != nil {
+		action.Fatalf(err.Error())
+	}
+
+	if client.input.dryRun {
+		client.enableDryRun()
+	}
+
+	// With lifecycle enabled only the pre phase resolves the refs, the post phase finishes the commits it saved and the
+	// main phase does nothing
+	if !client.input.lifecycle || phase == phasePre {
+		if err := client.resolveRefs(ctx); err != nil {
+			action.Fatalf(err.Error())
+		}
+	}
+
+	if client.input.subcommand == commandWait {
+		result, err := client.waitForStatuses(ctx)
+		if err != nil {
+			action.Fatalf(err.Error())
+		}
+		setOutputs(result.outputs())
+		addStepSummary(result.summary(client.input.defaultTarget()))
+		if err := result.err(client.input.waitTimeoutOrDefault()); err != nil {
+			action.Fatalf(err.Error())
+		}
+		return
+	}
+
+	if client.input.lifecycle {
+		switch phase {
+		case phasePre:
+			results, state, err := client.startJob(ctx, time.Now())
+			saveState(state)
+			client.report(results, err)
+		case phasePost:
+			client.report(client.finishJob(ctx, getState, time.Now()))
+		default:
+			action.Infof("lifecycle is enabled, statuses are posted by the pre and post phases")
+		}
+		return
+	}
+
+	if len(client.input.command) > 0 {
+		res, results, err := client.wrapCommand(ctx, os.Stdout, os.Stderr)
+		setOutputs(client.statusOutputs(results))
+		if len(results) > 0 {
+			summary := client.statusSummary(results)
+			if len(res.tail) > 0 {
+				summary += "\n" + commandSummary(client.input.command, res)
+			}
+			addStepSummary(summary)
+		}
+		if err != nil {
+			action.Fatalf(err.Error())
+		}
+		os.Exit(res.exitCode)
+	}
+
+	if client.input.subcommand == commandRollup {
+		client.report(client.rollup(ctx))
+		return
+	}
+
+	if client.input.subcommand == commandGet || client.input.subcommand == commandList {
+		listing, err := client.listStatuses(ctx)
+		if err != nil {
+			action.Fatalf(err.Error())
+		}
+		setOutputs(listing.outputs())
+		addStepSummary(listing.summary())
+		return
+	}
+
+	client.report(client.publish(ctx))
+}
+
+// report writes the outputs and job summary for the results and fails the step when posting failed.
+func (gh *ghClient) report(results []statusResult, err error) {
+	outputs := gh.statusOutputs(results)
+	if gh.dryRun != nil {
+		requests := gh.dryRun.json()
+		action.Infof("Dry run, these requests were not sent:\n%s", requests)
+		outputs["dry_run"] = requests
+	}
+	setOutputs(outputs)
+	if len(results) > 0 {
+		addStepSummary(gh.statusSummary(results))
 	}
-	err = client.createStatus(ctx)
 	if err != nil {
-		actions.Fatalf(err.Error())
+		action.Fatalf(err.Error())
 	}
 }
 
@@ -66,57 +271,171 @@ func newGHClient(ctx context.Context, maxConnectionRetries uint64, getInputFunc
 		return ghClient{}, err
 	}
 
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: in.token},
-	)
+	apiURL := getAPIURL(in.apiURL)
+	ts, err := newTokenSource(ctx, in, apiURL)
+	if err != nil {
+		return ghClient{}, err
+	}
 	tc := oauth2.NewClient(ctx, ts)
+	if in.subcommand == commandWait {
+		// Polling reads the same statuses over and over, which conditional requests make cheap
+		tc.Transport = &etagTransport{base: tc.Transport}
+	}
 
-	client := github.NewClient(tc).Repositories
+	client, err := newGitHubClient(tc, apiURL)
+	if err != nil {
+		return ghClient{}, err
+	}
 
-	return ghClient{
-		client:               client,
+	gh := ghClient{
+		client:               client.Repositories,
+		checks:               client.Checks,
+		workflows:            client.Actions,
+		git:                  client.Git,
+		pulls:                client.PullRequests,
+		commits:              client.Repositories,
 		input:                in,
+		serverURL:            getServerURL(in.apiURL, apiURL),
 		maxConnectionRetries: maxConnectionRetries,
-	}, nil
+	}
+	switch in.provider {
+	case providerGitLab:
+		gitlab := newGitLabClient(in)
+		gh.client = gitlab
+		gh.serverURL = gitlabServerURL(gitlab.apiURL)
+	case providerBitbucket, providerBitbucketDataCenter:
+		bitbucket := newBitbucketClient(in)
+		gh.client = bitbucket
+		gh.serverURL = bitbucket.serverURL()
+	case providerGitea:
+		gitea := newGiteaClient(in)
+		gh.client = gitea
+		gh.serverURL = giteaServerURL(gitea.apiURL)
+	}
+	return gh, nil
 }
 
-// createStatus creates a new GitHub repo status.
-func (gh *ghClient) createStatus(ctx context.Context) error {
-	// Do a fibonacci backoff 1s -> 1s -> 2s -> 3s -> 5s -> 8s
-	var err error
+// publish creates the check run or the repo statuses depending on the mode.
+func (gh *ghClient) publish(ctx context.Context) ([]statusResult, error) {
+	if gh.input.mode == modeCheckRun {
+		return gh.createCheckRun(ctx)
+	}
+	return gh.createStatus(ctx)
+}
+
+// createStatus creates a new GitHub repo status for every status entry on every target. The statuses are posted
+// concurrently by a bounded pool of workers. Every status is posted even when another one fails and the failures are
+// returned together with the result of every status.
+func (gh *ghClient) createStatus(ctx context.Context) ([]statusResult, error) {
+	var jobs []statusJob
+	for _, t := range gh.input.statusTargets() {
+		in, err := gh.input.forTarget(t)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t, err)
+		}
+		for _, entry := range in.statusEntries() {
+			jobs = append(jobs, statusJob{target: t, entry: entry})
+		}
+	}
+
+	var current map[target]map[string]*github.RepoStatus
+	if gh.input.idempotent {
+		current = gh.currentStatuses(ctx, gh.input.statusTargets())
+	}
+
+	results := make([]statusResult, len(jobs))
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < gh.input.workers(len(jobs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				if status := current[jobs[i].target][jobs[i].entry.Context]; isCurrent(status, jobs[i].entry) {
+					results[i] = skippedResult(jobs[i], status)
+					continue
+				}
+				results[i] = gh.postStatus(ctx, jobs[i].target, jobs[i].entry)
+			}
+		}()
+	}
+	for i := range jobs {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	// Report in the same order as the inputs rather than the order the workers finished in.
+	var errs *multierror.Error
+	for i, job := range jobs {
+		if results[i].err != nil {
+			action.Errorf("Failed: %s on %s", job.entry.Context, job.target)
+			errs = multierror.Append(errs, fmt.Errorf("%s on %s: %w", job.entry.Context, job.target, results[i].err))
+			continue
+		}
+		if results[i].skipped {
+			action.Infof("Skipped: %s on %s is already %s", job.entry.Context, job.target, job.entry.State)
+			continue
+		}
+		// Nothing was sent in a dry run, so nothing succeeded either
+		if gh.dryRun != nil {
+			action.Infof("Would post: %s as %s on %s", job.entry.Context, job.entry.State, job.target)
+			continue
+		}
+		action.Infof("Succeeded: %s on %s", job.entry.Context, job.target)
+	}
+
+	if errs != nil {
+		errs.ErrorFormat = joinErrors
+		return results, errs
+	}
+
+	return results, nil
+}
+
+// postStatus creates a single GitHub repo status on the target.
+func (gh *ghClient) postStatus(ctx context.Context, t target, entry statusEntry) statusResult {
 	var status *github.RepoStatus
-	err = retry.Do(ctx, retry.WithMaxRetries(gh.maxConnectionRetries, retry.NewFibonacci(1*time.Second)), func(ctx context.Context) error {
+	err := gh.withRetry(ctx, t, gh.input.statusPermission(), func(ctx context.Context) (*github.Response, error) {
 		// Create the status each time in case we retry. Also, because we pass this in with a pointer, we can't be
 		// certain that `createStatus` won't modify the status.
 		status = &github.RepoStatus{
-			State:       &gh.input.state,
-			Context:     &gh.input.context,
-			Description: &gh.input.description,
-			TargetURL:   &gh.input.detailsURL,
+			State:       github.String(entry.State),
+			Context:     github.String(entry.Context),
+			Description: github.String(entry.Description),
+			TargetURL:   github.String(entry.DetailsURL),
 		}
 
 		// This call will overwrite the original status.
-		status, _, err = gh.client.CreateStatus(context.Background(), gh.input.owner, gh.input.repository, gh.input.sha, status)
+		var resp *github.Response
+		var err error
+		status, resp, err = gh.client.CreateStatus(ctx, t.owner, t.repository, t.sha, status)
 		if err != nil {
-			actions.Errorf("Error creating status %v. Owner: %s, SHA: %s, Repo %s: %s", gh.input.state, gh.input.owner, gh.input.sha, gh.input.repository, err.Error())
-			// This marks the error as retryable
-			return retry.RetryableError(err)
+			action.Errorf("Error creating status %v for %s. Owner: %s, SHA: %s, Repo %s: %s", entry.State, entry.Context, t.owner, t.sha, t.repository, err.Error())
 		}
-		return nil
+		return resp, err
 	})
 
+	result := statusResult{target: t, entry: entry}
 	if err != nil {
-		return err
+		result.err = err
+		return result
 	}
 
 	// We are going to access the status ID so make sure it is valid before proceeding.
 	if status.ID == nil {
-		return errors.New("status ID returned is nil")
+		result.err = errors.New("status ID returned is nil")
+		return result
 	}
+	result.id = *status.ID
+	result.url = status.GetURL()
+	result.createdAt = status.GetCreatedAt().Time
 
-	commitURL := fmt.Sprintf("https://github.com/%s/%s/commits/%s", gh.input.owner, gh.input.repository, gh.input.sha)
-	actions.Infof("Updated status: \nID: %d \nState: %s \nURL: %s ", *status.ID, gh.input.state, commitURL)
-	return nil
+	if gh.dryRun != nil {
+		return result
+	}
+	action.Infof("Updated status: \nID: %d \nContext: %s \nState: %s \nURL: %s ", *status.ID, entry.Context, entry.State, gh.commitURL(t))
+	return result
 }
 
 // getInputs loads in all the inputs from the action and returns them as a struct.
@@ -131,14 +450,131 @@ func getInputs(getInput getInputFunc) (input, error) {
 		repository:  getInput("repository"),
 		sha:         getInput("sha"),
 		detailsURL:  getInput("details_url"),
+		mode:        getInput("mode"),
+		title:       getInput("title"),
+		summary:     getInput("summary"),
+		text:        getInput("text"),
+		apiURL:      getInput("api_url"),
+		privateKey:  getInput("private_key"),
+		shaSource:   getInput("sha_source"),
+		provider:    getInput("provider"),
+		username:    getInput("username"),
+
+		descriptionOverflow: getInput("description_overflow"),
+		subcommand:          getInput("command"),
+		contexts:            parseContexts(getInput("contexts")),
 	}
 
-	// Convert State to a repo status
 	var err error
-	in.state, err = convertActionStateToRepoStatusState(in.state)
+	in.checkRunID, err = getIDInput(getInput, "check_run_id")
+	if err != nil {
+		return input{}, err
+	}
+	in.appID, err = getIDInput(getInput, "app_id")
 	if err != nil {
 		return input{}, err
 	}
+	in.installationID, err = getIDInput(getInput, "installation_id")
+	if err != nil {
+		return input{}, err
+	}
+	in.pipelineID, err = getIDInput(getInput, "pipeline_id")
+	if err != nil {
+		return input{}, err
+	}
+
+	in.stateMap, err = parseStateMap(getInput("state_map"), in.statusStates())
+	if err != nil {
+		return input{}, err
+	}
+
+	in.lifecycle, err = getBoolInput(getInput, "lifecycle")
+	if err != nil {
+		return input{}, err
+	}
+	in.template, err = getBoolInput(getInput, "template")
+	if err != nil {
+		return input{}, err
+	}
+	in.idempotent, err = getBoolInput(getInput, "idempotent")
+	if err != nil {
+		return input{}, err
+	}
+	in.dryRun, err = getBoolInput(getInput, "dry_run")
+	if err != nil {
+		return input{}, err
+	}
+
+	if run := getInput("run"); run != "" {
+		in.command, err = splitCommand(run)
+		if err != nil {
+			return input{}, err
+		}
+	}
+
+	// Check runs have their own status and conclusion so convert those from the action state first
+	if in.mode == modeCheckRun && in.state != "" {
+		in.checkStatus, in.conclusion, err = convertActionStateToCheckRunStatus(in.state, in.stateMap)
+		if err != nil {
+			return input{}, err
+		}
+	}
+
+	if annotationsFile := getInput("annotations_file"); annotationsFile != "" {
+		if in.mode != modeCheckRun {
+			return input{}, errors.New(annotationsModeErr)
+		}
+		in.annotations, err = getAnnotations(annotationsFile, getInput("annotations_format"), getInput("annotations_matcher"))
+		if err != nil {
+			return input{}, err
+		}
+	}
+
+	if statuses := getInput("statuses"); statuses != "" {
+		if in.mode == modeCheckRun {
+			return input{}, errors.New(statusesModeErr)
+		}
+		in.statuses, err = parseStatuses(statuses, in.convertState)
+		if err != nil {
+			return input{}, err
+		}
+	}
+
+	in.targets, err = parseTargets(getInput("targets"), in.provider == providerGitLab)
+	if err != nil {
+		return input{}, err
+	}
+	if len(in.targets) > 0 && in.mode == modeCheckRun {
+		return input{}, errors.New(targetsModeErr)
+	}
+	if maxRateLimitWait := getInput("max_rate_limit_wait"); maxRateLimitWait != "" {
+		in.maxRateLimitWait, err = time.ParseDuration(maxRateLimitWait)
+		if err != nil || in.maxRateLimitWait < 0 {
+			return input{}, fmt.Errorf("max_rate_limit_wait is not a valid duration: %s", maxRateLimitWait)
+		}
+	}
+	in.waitTimeout, err = getDurationInput(getInput, "timeout")
+	if err != nil {
+		return input{}, err
+	}
+	in.pollInterval, err = getDurationInput(getInput, "poll_interval")
+	if err != nil {
+		return input{}, err
+	}
+	if maxConcurrency := getInput("max_concurrency"); maxConcurrency != "" {
+		in.maxConcurrency, err = strconv.Atoi(maxConcurrency)
+		if err != nil || in.maxConcurrency < 1 {
+			return input{}, fmt.Errorf("max_concurrency must be a positive number: %s", maxConcurrency)
+		}
+	}
+
+	// Convert State to a state of the provider unless it comes from somewhere else
+	if in.state != "" || in.needsState() {
+		in.state, err = in.convertState(in.state)
+		if err != nil {
+			return input{}, err
+		}
+	}
 
 	// Set Input Defaults
 	in, err = setInputDefaults(in)
@@ -146,8 +582,23 @@ func getInputs(getInput getInputFunc) (input, error) {
 		return input{}, err
 	}
 
+	// Render the templates once every field they can use is known
+	if valuesFile := getInput("values_file"); valuesFile != "" {
+		in.templateValues, err = readTemplateValues(valuesFile)
+		if err != nil {
+			return input{}, err
+		}
+	}
+	in.descriptionTemplate = in.description
+	in.detailsURLTemplate = in.detailsURL
+	in.statusTemplates = append([]statusEntry(nil), in.statuses...)
+	in, err = in.renderTemplates()
+	if err != nil {
+		return input{}, err
+	}
+
 	// Validate inputs before proceeding
-	err = validateRequiredInputs(in)
+	in, err = validateRequiredInputs(in)
 	if err != nil {
 		return input{}, err
 	}
@@ -158,6 +609,10 @@ func getInputs(getInput getInputFunc) (input, error) {
 // setInputDefaults sets the default values for inputs that are not required.
 func setInputDefaults(in input) (input, error) {
 	// Set Defaults
+	// A repository given as owner/repo also sets the owner
+	if owner, _, ok := strings.Cut(in.repository, "/"); ok && in.owner == "" {
+		in.owner = owner
+	}
 	if in.owner == "" {
 		owner, err := getOwner()
 		if err != nil {
@@ -176,41 +631,156 @@ func setInputDefaults(in input) (input, error) {
 	in.repository = removeOwnerFromRepository(in.repository, in.owner)
 
 	if in.sha == "" {
-		sha, err := getSHA()
+		sha, err := getSHA(in.shaSource)
 		if err != nil {
 			return input{}, err
 		}
 		in.sha = sha
 	}
 
+	// Targets without a SHA use the same commit as the inputs
+	for i := range in.targets {
+		if in.targets[i].sha == "" {
+			in.targets[i].sha = in.sha
+		}
+	}
+
 	return in, nil
 }
 
-// validateRequiredInputs validates that all required inputs are set.
-func validateRequiredInputs(in input) error {
+// validateRequiredInputs validates that all required inputs are set and that the statuses will be accepted by GitHub.
+// Descriptions that are too long are truncated unless description_overflow is error.
+func validateRequiredInputs(in input) (input, error) {
 	// Accumulate errors
 	var errs *multierror.Error
 
-	if in.token == "" {
+	// A GitHub App mints its own token
+	if in.token == "" && in.appID == 0 {
 		errs = multierror.Append(errs, errors.New(tokenRequiredErr))
 	}
 
-	if in.state == "" {
+	if in.appID != 0 && in.privateKey == "" {
+		errs = multierror.Append(errs, errors.New(privateKeyRequiredErr))
+	}
+
+	if in.state == "" && in.needsState() {
 		errs = multierror.Append(errs, errors.New(stateRequiredErr))
 	}
 
-	if errs != nil {
-		errs.ErrorFormat = func(errs []error) string {
-			var errStr []string
-			for _, e := range errs {
-				errStr = append(errStr, e.Error())
-			}
-			return strings.Join(errStr, ", ")
+	if in.lifecycle && len(in.command) > 0 {
+		errs = multierror.Append(errs, errors.New(lifecycleRunErr))
+	}
+
+	// The pre and post phases post statuses, so the commands that read statuses have nothing to do with them
+	if in.lifecycle && in.subcommand != "" && in.subcommand != commandSet {
+		errs = multierror.Append(errs, fmt.Errorf("lifecycle is not supported with command %s", in.subcommand))
+	}
+
+	if in.mode != "" && in.mode != modeStatus && in.mode != modeCheckRun {
+		errs = multierror.Append(errs, fmt.Errorf("mode value not supported: %s", in.mode))
+	}
+
+	switch in.subcommand {
+	case "", commandSet:
+	case commandWait:
+		// wait polls the commit statuses of a single commit
+		if len(in.statuses) > 0 {
+			errs = multierror.Append(errs, errors.New(waitStatusesErr))
+		}
+		if len(in.targets) > 0 {
+			errs = multierror.Append(errs, errors.New(waitTargetsErr))
+		}
+		if in.mode == modeCheckRun {
+			errs = multierror.Append(errs, errors.New(waitModeErr))
 		}
-		return errs
+	case commandGet, commandList:
+		if in.subcommand == commandGet && in.context == "" {
+			errs = multierror.Append(errs, errors.New(getContextRequiredErr))
+		}
+		if len(in.statuses) > 0 {
+			errs = multierror.Append(errs, errors.New(listStatusesErr))
+		}
+		if len(in.targets) > 0 {
+			errs = multierror.Append(errs, errors.New(listTargetsErr))
+		}
+		if err := validatePatterns(in.contexts); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	case commandRollup:
+		if in.context == "" {
+			errs = multierror.Append(errs, errors.New(rollupContextRequiredErr))
+		}
+		if len(in.statuses) > 0 {
+			errs = multierror.Append(errs, errors.New(rollupStatusesErr))
+		}
+		if len(in.targets) > 0 {
+			errs = multierror.Append(errs, errors.New(rollupTargetsErr))
+		}
+		if err := validatePatterns(in.contexts); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	default:
+		errs = multierror.Append(errs, fmt.Errorf("command value not supported: %s", in.subcommand))
+	}
+
+	for _, err := range validateProvider(in) {
+		errs = multierror.Append(errs, err)
+	}
+
+	if in.dryRun && len(in.command) > 0 {
+		errs = multierror.Append(errs, errors.New(dryRunRunErr))
+	}
+
+	if in.idempotent && in.mode == modeCheckRun {
+		errs = multierror.Append(errs, errors.New(idempotentModeErr))
+	}
+
+	if in.descriptionOverflow != "" && in.descriptionOverflow != descriptionOverflowTruncate && in.descriptionOverflow != descriptionOverflowError {
+		errs = multierror.Append(errs, fmt.Errorf("description_overflow value not supported: %s", in.descriptionOverflow))
+	}
+
+	in, err := in.fitDescriptions()
+	if err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	if err := validateDetailsURL("details_url", in.detailsURL); err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	if err := validateContext("context", in.context); err != nil {
+		errs = multierror.Append(errs, err)
+	}
+
+	for i, entry := range in.statuses {
+		if err := validateDetailsURL(fmt.Sprintf("statuses[%d].details_url", i), entry.DetailsURL); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+		if err := validateContext(fmt.Sprintf("statuses[%d].context", i), entry.Context); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+
+	if errs != nil {
+		errs.ErrorFormat = joinErrors
+		return input{}, errs
 	}
 
-	return nil
+	return in, nil
+}
+
+// needsState reports whether the state input is needed. The state can be left out when every status sets its own,
+// when it comes from a wrapped command, the job outcome or a rollup, or when waiting for statuses.
+func (in input) needsState() bool {
+	return len(in.statuses) == 0 && len(in.command) == 0 && !in.lifecycle &&
+		in.subcommand != commandWait && in.subcommand != commandRollup && in.subcommand != commandGet && in.subcommand != commandList
+}
+
+// joinErrors formats accumulated errors on a single line.
+func joinErrors(errs []error) string {
+	var errStr []string
+	for _, e := range errs {
+		errStr = append(errStr, e.Error())
+	}
+	return strings.Join(errStr, ", ")
 }
 
 // getOwner gets github.repository_owner from the GitHub API.
@@ -231,8 +801,22 @@ func getRepository() (string, error) {
 	return repo, nil
 }
 
-// getSHA gets github.repository_owner from the GitHub API.
-func getSHA() (string, error) {
+// getSHA gets the SHA of the commit the event is about, falling back to GITHUB_SHA.
+func getSHA(source string) (string, error) {
+	switch source {
+	case "", shaSourceEvent:
+		sha, err := eventSHA(os.Getenv("GITHUB_EVENT_NAME"), os.Getenv("GITHUB_EVENT_PATH"))
+		if err != nil {
+			return "", err
+		}
+		if sha != "" {
+			return sha, nil
+		}
+	case shaSourceGitHubSHA:
+	default:
+		return "", fmt.Errorf("sha_source value not supported: %s", source)
+	}
+
 	sha := os.Getenv("GITHUB_SHA")
 	if sha == "" {
 		return "", fmt.Errorf(shaEnvNotSetErr)
@@ -240,19 +824,58 @@ func getSHA() (string, error) {
 	return sha, nil
 }
 
-// convertActionStateToRepoStatusState validates that the state is a correct value. If the state is
-// 'cancel', 'cancelled'  or 'skipped' then return 'error' as the state.
+// convertActionStateToRepoStatusState validates that the state is a correct value and converts it to a repo status
+// state, ignoring case. The mappings from state_map come first, then the defaults, which map 'cancel', 'cancelled' and
+// 'skipped' to 'error' and the statuses and conclusions of the Checks API to the closest state.
 // 'Cancelled' can be a valid state if a workflow is cancelled.
-func convertActionStateToRepoStatusState(actionState string) (string, error) {
-	switch actionState {
-	// success, error, failure or pending
-	case "error", "failure", "pending", "success":
-		return actionState, nil
-	case "cancel", "cancelled", "skipped":
-		return "error", nil
-	default:
-		return "", fmt.Errorf("state value not supported: %s", actionState)
+func convertActionStateToRepoStatusState(actionState string, stateMap map[string]string) (string, error) {
+	key := strings.ToLower(strings.TrimSpace(actionState))
+	if state, ok := stateMap[key]; ok {
+		return state, nil
+	}
+	if state, ok := defaultStateMap[key]; ok {
+		return state, nil
+	}
+	return "", fmt.Errorf("state value not supported: %s", actionState)
+}
+
+// getIDInput reads an input that holds a GitHub ID. An empty input returns zero.
+func getIDInput(getInput getInputFunc, name string) (int64, error) {
+	value := getInput(name)
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s is not a valid ID: %s", name, value)
+	}
+	return id, nil
+}
+
+// getDurationInput reads an input that holds a positive duration like 30s or 5m. An empty input returns zero.
+func getDurationInput(getInput getInputFunc, name string) (time.Duration, error) {
+	value := getInput(name)
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s is not a valid duration: %s", name, value)
+	}
+	return d, nil
+}
+
+// getBoolInput reads an input that is true or false. An empty input returns false.
+func getBoolInput(getInput getInputFunc, name string) (bool, error) {
+	value := getInput(name)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false: %s", name, value)
 	}
+	return b, nil
 }
 
 // removeOwnerFromRepository removes the owner from the repository string.
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"testing"
 
 	"github.com/google/go-github/v53/github"
@@ -46,10 +47,34 @@ func TestGetAndValidateState(t *testing.T) {
 			expectedValue: "error",
 			expectError:   false,
 		},
+		{
+			name:          "case_insensitive",
+			actual:        "Success",
+			expectedValue: "success",
+			expectError:   false,
+		},
+		{
+			name:          "change_timed_out_to_failure",
+			actual:        "timed_out",
+			expectedValue: "failure",
+			expectError:   false,
+		},
+		{
+			name:          "change_neutral_to_success",
+			actual:        "neutral",
+			expectedValue: "success",
+			expectError:   false,
+		},
+		{
+			name:          "change_in_progress_to_pending",
+			actual:        "in_progress",
+			expectedValue: "pending",
+			expectError:   false,
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			value, err := convertActionStateToRepoStatusState(c.actual)
+			value, err := convertActionStateToRepoStatusState(c.actual, nil)
 			assert.Equal(t, c.expectedValue, value)
 			if err != nil {
 				assert.Equal(t, c.expectError, true)
@@ -84,18 +109,174 @@ func TestRequiredInputs(t *testing.T) {
 			},
 			expErr: "",
 		},
+		{
+			name: "token_and_statuses_inputs_returns_no_errors",
+			inputs: input{
+				token:    "foo",
+				statuses: []statusEntry{{Context: "bar", State: "success"}},
+			},
+			expErr: "",
+		},
+		{
+			name: "app_inputs_returns_no_errors",
+			inputs: input{
+				appID:      24601,
+				privateKey: "foo",
+				state:      "bar",
+			},
+			expErr: "",
+		},
+		{
+			name: "app_without_private_key_returns_error",
+			inputs: input{
+				appID: 24601,
+				state: "bar",
+			},
+			expErr: privateKeyRequiredErr,
+		},
+		{
+			name: "token_and_lifecycle_inputs_returns_no_errors",
+			inputs: input{
+				token:     "foo",
+				lifecycle: true,
+			},
+			expErr: "",
+		},
+		{
+			name: "lifecycle_with_command_returns_error",
+			inputs: input{
+				token:     "foo",
+				lifecycle: true,
+				command:   []string{"true"},
+			},
+			expErr: lifecycleRunErr,
+		},
+		{
+			name: "lifecycle_with_set_returns_no_errors",
+			inputs: input{
+				token:      "foo",
+				subcommand: commandSet,
+				lifecycle:  true,
+			},
+			expErr: "",
+		},
+		{
+			name: "lifecycle_with_get_returns_error",
+			inputs: input{
+				token:      "foo",
+				subcommand: commandGet,
+				context:    "ci",
+				lifecycle:  true,
+			},
+			expErr: "lifecycle is not supported with command get",
+		},
+		{
+			name: "lifecycle_with_list_returns_error",
+			inputs: input{
+				token:      "foo",
+				subcommand: commandList,
+				lifecycle:  true,
+			},
+			expErr: "lifecycle is not supported with command list",
+		},
+		{
+			name: "lifecycle_with_wait_returns_error",
+			inputs: input{
+				token:      "foo",
+				subcommand: commandWait,
+				lifecycle:  true,
+			},
+			expErr: "lifecycle is not supported with command wait",
+		},
+		{
+			name: "lifecycle_with_rollup_returns_error",
+			inputs: input{
+				token:      "foo",
+				subcommand: commandRollup,
+				context:    "ci/all",
+				lifecycle:  true,
+			},
+			expErr: "lifecycle is not supported with command rollup",
+		},
+		{
+			name: "get_without_context_returns_error",
+			inputs: input{
+				token:      "foo",
+				subcommand: commandGet,
+			},
+			expErr: getContextRequiredErr,
+		},
+		{
+			name: "list_with_targets_returns_error",
+			inputs: input{
+				token:      "foo",
+				subcommand: commandList,
+				targets:    []target{{owner: "foo", repository: "bar"}},
+			},
+			expErr: listTargetsErr,
+		},
+		{
+			name: "wait_with_statuses_returns_error",
+			inputs: input{
+				token:      "foo",
+				subcommand: commandWait,
+				statuses:   []statusEntry{{Context: "ci", State: "success"}},
+			},
+			expErr: waitStatusesErr,
+		},
+		{
+			name: "wait_with_targets_returns_error",
+			inputs: input{
+				token:      "foo",
+				subcommand: commandWait,
+				targets:    []target{{owner: "foo", repository: "bar"}},
+			},
+			expErr: waitTargetsErr,
+		},
+		{
+			name: "wait_with_check_run_returns_error",
+			inputs: input{
+				token:      "foo",
+				subcommand: commandWait,
+				mode:       modeCheckRun,
+			},
+			expErr: waitModeErr,
+		},
+		{
+			name: "dry_run_with_command_returns_error",
+			inputs: input{
+				token:   "foo",
+				dryRun:  true,
+				command: []string{"true"},
+			},
+			expErr: dryRunRunErr,
+		},
+		{
+			name: "unsupported_mode_returns_error",
+			inputs: input{
+				token: "foo",
+				state: "bar",
+				mode:  "foo",
+			},
+			expErr: "mode value not supported: foo",
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			err := validateRequiredInputs(c.inputs)
+			_, err := validateRequiredInputs(c.inputs)
 			if c.expErr != "" {
 				require.EqualError(t, err, c.expErr)
+			} else {
+				require.NoError(t, err)
 			}
 		})
 	}
 }
 
 func TestSetInputDefaults(t *testing.T) {
+	// Use GITHUB_SHA even when the tests run in a workflow
+	t.Setenv("GITHUB_EVENT_NAME", "")
+
 	cases := []struct {
 		name   string
 		inputs input
@@ -124,7 +305,7 @@ func TestSetInputDefaults(t *testing.T) {
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			err := validateRequiredInputs(c.inputs)
+			_, err := validateRequiredInputs(c.inputs)
 			if c.expErr != "" {
 				require.EqualError(t, err, c.expErr)
 			}
@@ -132,6 +313,16 @@ func TestSetInputDefaults(t *testing.T) {
 	}
 }
 
+func TestSetInputDefaultsOwnerFromRepository(t *testing.T) {
+	t.Setenv("GITHUB_OWNER", "")
+	t.Setenv("GITHUB_REPOSITORY", "")
+
+	in, err := setInputDefaults(input{repository: "foo/bar", sha: "abc"})
+	require.NoError(t, err)
+	require.Equal(t, "foo", in.owner)
+	require.Equal(t, "bar", in.repository)
+}
+
 func TestGetOwnerEnvironmentVariable(t *testing.T) {
 	cases := []struct {
 		name        string
@@ -203,6 +394,9 @@ func TestGetRepositoryEnvironmentVariable(t *testing.T) {
 }
 
 func TestGetSHAEnvironmentVariable(t *testing.T) {
+	// Use GITHUB_SHA even when the tests run in a workflow
+	t.Setenv("GITHUB_EVENT_NAME", "")
+
 	cases := []struct {
 		name        string
 		actual      string
@@ -228,7 +422,7 @@ func TestGetSHAEnvironmentVariable(t *testing.T) {
 			// across cases
 			err := os.Setenv("GITHUB_SHA", c.sha)
 			require.NoError(t, err)
-			got, err := getSHA()
+			got, err := getSHA("")
 			require.Equal(t, c.sha, got)
 			if c.expectedErr != nil {
 				require.Equal(t, c.expectedErr, err)
@@ -266,6 +460,9 @@ func TestRemoveOwnerFromRepo(t *testing.T) {
 }
 
 func TestGetInputs(t *testing.T) {
+	// Use GITHUB_SHA even when the tests run in a workflow
+	t.Setenv("GITHUB_EVENT_NAME", "")
+
 	cases := []struct {
 		name          string
 		inputs        input
@@ -284,18 +481,20 @@ func TestGetInputs(t *testing.T) {
 				description: "some-description",
 				owner:       "some-owner",
 				repository:  "some-owner/some-repo",
-				detailsURL:  "some-url",
+				detailsURL:  "https://some-url",
 				sha:         "some-sha",
 			},
 			expected: input{
-				token:       "some-token",
-				state:       "success",
-				context:     "some-context",
-				description: "some-description",
-				owner:       "some-owner",
-				repository:  "some-repo",
-				detailsURL:  "some-url",
-				sha:         "some-sha",
+				token:               "some-token",
+				state:               "success",
+				context:             "some-context",
+				description:         "some-description",
+				owner:               "some-owner",
+				repository:          "some-repo",
+				detailsURL:          "https://some-url",
+				descriptionTemplate: "some-description",
+				detailsURLTemplate:  "https://some-url",
+				sha:                 "some-sha",
 			},
 		},
 		{
@@ -307,18 +506,51 @@ func TestGetInputs(t *testing.T) {
 				description: "some-description",
 				owner:       "some-owner",
 				repository:  "some-repo",
-				detailsURL:  "some-url",
+				detailsURL:  "https://some-url",
 				sha:         "some-sha",
 			},
 			expected: input{
+				token:               "some-token",
+				state:               "error",
+				context:             "some-context",
+				description:         "some-description",
+				owner:               "some-owner",
+				repository:          "some-repo",
+				detailsURL:          "https://some-url",
+				descriptionTemplate: "some-description",
+				detailsURLTemplate:  "https://some-url",
+				sha:                 "some-sha",
+			},
+		},
+		{
+			name: "check_run_state_converted",
+			inputs: input{
 				token:       "some-token",
-				state:       "error",
+				state:       "cancelled",
 				context:     "some-context",
 				description: "some-description",
 				owner:       "some-owner",
 				repository:  "some-repo",
-				detailsURL:  "some-url",
+				detailsURL:  "https://some-url",
 				sha:         "some-sha",
+				mode:        modeCheckRun,
+				checkRunID:  24601,
+			},
+			expected: input{
+				token:               "some-token",
+				state:               "error",
+				context:             "some-context",
+				description:         "some-description",
+				owner:               "some-owner",
+				repository:          "some-repo",
+				detailsURL:          "https://some-url",
+				descriptionTemplate: "some-description",
+				detailsURLTemplate:  "https://some-url",
+				sha:                 "some-sha",
+				mode:                modeCheckRun,
+				checkRunID:          24601,
+				checkStatus:         "completed",
+				conclusion:          "cancelled",
 			},
 		},
 		{
@@ -330,18 +562,20 @@ func TestGetInputs(t *testing.T) {
 				description: "some-description",
 				owner:       "",
 				repository:  "",
-				detailsURL:  "some-url",
+				detailsURL:  "https://some-url",
 				sha:         "",
 			},
 			expected: input{
-				token:       "some-token",
-				state:       "error",
-				context:     "some-context",
-				description: "some-description",
-				owner:       "env-owner",
-				repository:  "env-repo",
-				detailsURL:  "some-url",
-				sha:         "env-sha",
+				token:               "some-token",
+				state:               "error",
+				context:             "some-context",
+				description:         "some-description",
+				owner:               "env-owner",
+				repository:          "env-repo",
+				detailsURL:          "https://some-url",
+				descriptionTemplate: "some-description",
+				detailsURLTemplate:  "https://some-url",
+				sha:                 "env-sha",
 			},
 			inputEnvOwner: "env-owner",
 			inputEnvRepo:  "env-repo",
@@ -356,7 +590,7 @@ func TestGetInputs(t *testing.T) {
 				description: "some-description",
 				owner:       "some-owner",
 				repository:  "some-repo",
-				detailsURL:  "some-url",
+				detailsURL:  "https://some-url",
 				sha:         "some-sha",
 			},
 			expected:    input{},
@@ -371,7 +605,7 @@ func TestGetInputs(t *testing.T) {
 				description: "some-description",
 				owner:       "some-owner",
 				repository:  "some-repo",
-				detailsURL:  "some-url",
+				detailsURL:  "https://some-url",
 				sha:         "some-sha",
 			},
 			expected:    input{},
@@ -386,7 +620,7 @@ func TestGetInputs(t *testing.T) {
 				description: "some-description",
 				owner:       "",
 				repository:  "",
-				detailsURL:  "some-url",
+				detailsURL:  "https://some-url",
 				sha:         "",
 			},
 			expected:      input{},
@@ -404,7 +638,7 @@ func TestGetInputs(t *testing.T) {
 				description: "some-description",
 				owner:       "",
 				repository:  "",
-				detailsURL:  "some-url",
+				detailsURL:  "https://some-url",
 				sha:         "",
 			},
 			expected:      input{},
@@ -422,7 +656,7 @@ func TestGetInputs(t *testing.T) {
 				description: "some-description",
 				owner:       "",
 				repository:  "",
-				detailsURL:  "some-url",
+				detailsURL:  "https://some-url",
 				sha:         "",
 			},
 			expected:      input{},
@@ -459,7 +693,7 @@ func TestCreateStatus(t *testing.T) {
 	cases := []struct {
 		name         string
 		inputs       input
-		ghRepoClient ghRepositoryClient
+		ghRepoClient statusProvider
 		expectError  string
 	}{
 		{
@@ -471,7 +705,7 @@ func TestCreateStatus(t *testing.T) {
 				description: "some-description",
 				owner:       "some-owner",
 				repository:  "some-repo",
-				detailsURL:  "some-url",
+				detailsURL:  "https://some-url",
 				sha:         "some-sha",
 			},
 			ghRepoClient: mockghRepositoryClient{
@@ -485,7 +719,7 @@ func TestCreateStatus(t *testing.T) {
 					description: "some-description",
 					owner:       "some-owner",
 					repository:  "some-repo",
-					detailsURL:  "some-url",
+					detailsURL:  "https://some-url",
 					sha:         "some-sha",
 				},
 			},
@@ -499,7 +733,7 @@ func TestCreateStatus(t *testing.T) {
 				description: "some-description",
 				owner:       "some-owner",
 				repository:  "some-repo",
-				detailsURL:  "some-url",
+				detailsURL:  "https://some-url",
 				sha:         "some-sha",
 			},
 			ghRepoClient: mockghRepositoryClient{
@@ -513,7 +747,7 @@ func TestCreateStatus(t *testing.T) {
 					description: "some-description",
 					owner:       "some-owner",
 					repository:  "some-repo",
-					detailsURL:  "some-url",
+					detailsURL:  "https://some-url",
 					sha:         "some-sha",
 				},
 			},
@@ -528,7 +762,7 @@ func TestCreateStatus(t *testing.T) {
 				description: "some-description",
 				owner:       "some-owner",
 				repository:  "some-repo",
-				detailsURL:  "some-url",
+				detailsURL:  "https://some-url",
 				sha:         "some-sha",
 			},
 			ghRepoClient: mockghRepositoryClient{status: &github.RepoStatus{}, returnError: true, t: t},
@@ -539,8 +773,8 @@ func TestCreateStatus(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			ctx := context.Background()
-			gh := ghClient{c.ghRepoClient, c.inputs, uint64(0)}
-			err := gh.createStatus(ctx)
+			gh := ghClient{client: c.ghRepoClient, input: c.inputs, maxConnectionRetries: uint64(0)}
+			_, err := gh.createStatus(ctx)
 			if c.expectError != "" {
 				require.Contains(t, err.Error(), c.expectError)
 			} else {
@@ -572,6 +806,19 @@ func (m mockGetInput) GetInput(i string) string {
 		return m.in.description
 	case "details_url":
 		return m.in.detailsURL
+	case "mode":
+		return m.in.mode
+	case "title":
+		return m.in.title
+	case "summary":
+		return m.in.summary
+	case "text":
+		return m.in.text
+	case "check_run_id":
+		if m.in.checkRunID == 0 {
+			return ""
+		}
+		return strconv.FormatInt(m.in.checkRunID, 10)
 	default:
 		return ""
 	}
@@ -0,0 +1,139 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// statusResult is the outcome of posting a single status or check run.
+type statusResult struct {
+	target    target
+	entry     statusEntry
+	id        int64
+	url       string
+	createdAt time.Time
+	// skipped is set when the status was not posted because it was already current.
+	skipped bool
+	err     error
+}
+
+// resultOutput is a status result written to the results output.
+type resultOutput struct {
+	Context    string `json:"context"`
+	State      string `json:"state"`
+	Repository string `json:"repository"`
+	SHA        string `json:"sha"`
+	StatusID   int64  `json:"status_id,omitempty"`
+	StatusURL  string `json:"status_url,omitempty"`
+	CreatedAt  string `json:"created_at,omitempty"`
+	CommitURL  string `json:"commit_url"`
+	Skipped    bool   `json:"skipped,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// statusOutputs returns the step outputs for the results. The single value outputs describe the first status that was
+// created, while the results output lists every status as JSON. A dry run created nothing, so it only has the commit
+// that was resolved.
+func (gh *ghClient) statusOutputs(results []statusResult) map[string]string {
+	outputs := map[string]string{}
+	if gh.dryRun != nil {
+		if len(results) > 0 {
+			outputs["resolved_sha"] = results[0].target.sha
+			outputs["resolved_repository"] = fmt.Sprintf("%s/%s", results[0].target.owner, results[0].target.repository)
+		}
+		return outputs
+	}
+
+	list := make([]resultOutput, 0, len(results))
+	for _, r := range results {
+		repository := fmt.Sprintf("%s/%s", r.target.owner, r.target.repository)
+		output := resultOutput{
+			Context:    r.entry.Context,
+			State:      r.entry.State,
+			Repository: repository,
+			SHA:        r.target.sha,
+			CommitURL:  gh.commitURL(r.target),
+			Skipped:    r.skipped,
+		}
+		if r.err != nil {
+			output.Error = r.err.Error()
+			list = append(list, output)
+			continue
+		}
+		output.StatusID = r.id
+		output.StatusURL = r.url
+		output.CreatedAt = formatOutputTime(r.createdAt)
+		list = append(list, output)
+
+		if _, ok := outputs["status_id"]; !ok {
+			outputs["status_id"] = strconv.FormatInt(r.id, 10)
+			outputs["status_url"] = r.url
+			outputs["created_at"] = output.CreatedAt
+			outputs["commit_url"] = output.CommitURL
+			outputs["resolved_sha"] = r.target.sha
+			outputs["resolved_repository"] = repository
+		}
+	}
+
+	if len(list) > 0 {
+		// skipped is only true when every status was already current, so a workflow can tell nothing was posted
+		skipped := true
+		for _, r := range results {
+			skipped = skipped && r.skipped
+		}
+		outputs["skipped"] = strconv.FormatBool(skipped)
+
+		outputs["results"] = marshalJSON(list)
+	}
+	return outputs
+}
+
+// formatOutputTime formats a time for an output. A zero time is left empty.
+func formatOutputTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// setOutputs writes the outputs in a stable order.
+func setOutputs(outputs map[string]string) {
+	if !hasCommandFile("GITHUB_OUTPUT") {
+		return
+	}
+
+	keys := make([]string, 0, len(outputs))
+	for k := range outputs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		action.SetOutput(k, outputs[k])
+	}
+}
+
+// hasCommandFile reports whether the runner set the variable with the path of a file that commands are written to.
+// Outside of GitHub Actions there are no such files, so nothing is written.
+func hasCommandFile(name string) bool {
+	return os.Getenv(name) != ""
+}
+
+// marshalJSON marshals data that only holds strings, numbers and maps, slices and structs of those. That cannot fail,
+// so there is no error to return.
+func marshalJSON(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// marshalIndentJSON is marshalJSON indented for people to read.
+func marshalIndentJSON(v any) string {
+	data, _ := json.MarshalIndent(v, "", "  ")
+	return string(data)
+}
@@ -0,0 +1,115 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusOutputs(t *testing.T) {
+	createdAt := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		results  []statusResult
+		expected map[string]string
+	}{
+		{
+			name:     "no_results",
+			results:  nil,
+			expected: map[string]string{},
+		},
+		{
+			name: "first_created_status_is_used",
+			results: []statusResult{
+				{
+					target: target{owner: "foo", repository: "bar", sha: "abc"},
+					entry:  statusEntry{Context: "lint", State: "success"},
+					err:    errors.New("some-error"),
+				},
+				{
+					target:    target{owner: "foo", repository: "baz", sha: "def"},
+					entry:     statusEntry{Context: "lint", State: "success"},
+					id:        24601,
+					url:       "https://api.github.com/repos/foo/baz/statuses/def",
+					createdAt: createdAt,
+				},
+			},
+			expected: map[string]string{
+				"status_id":           "24601",
+				"status_url":          "https://api.github.com/repos/foo/baz/statuses/def",
+				"created_at":          "2023-06-01T12:00:00Z",
+				"commit_url":          "https://github.com/foo/baz/commits/def",
+				"resolved_sha":        "def",
+				"resolved_repository": "foo/baz",
+				"skipped":             "false",
+				"results": `[{"context":"lint","state":"success","repository":"foo/bar","sha":"abc","commit_url":"https://github.com/foo/bar/commits/abc","error":"some-error"},` +
+					`{"context":"lint","state":"success","repository":"foo/baz","sha":"def","status_id":24601,"status_url":"https://api.github.com/repos/foo/baz/statuses/def","created_at":"2023-06-01T12:00:00Z","commit_url":"https://github.com/foo/baz/commits/def"}]`,
+			},
+		},
+		{
+			name: "every_status_skipped",
+			results: []statusResult{
+				{
+					target:  target{owner: "foo", repository: "bar", sha: "abc"},
+					entry:   statusEntry{Context: "lint", State: "success"},
+					id:      24601,
+					skipped: true,
+				},
+			},
+			expected: map[string]string{
+				"status_id":           "24601",
+				"status_url":          "",
+				"created_at":          "",
+				"commit_url":          "https://github.com/foo/bar/commits/abc",
+				"resolved_sha":        "abc",
+				"resolved_repository": "foo/bar",
+				"skipped":             "true",
+				"results":             `[{"context":"lint","state":"success","repository":"foo/bar","sha":"abc","status_id":24601,"commit_url":"https://github.com/foo/bar/commits/abc","skipped":true}]`,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gh := ghClient{}
+			require.Equal(t, c.expected, gh.statusOutputs(c.results))
+		})
+	}
+}
+
+func TestStatusOutputsDryRun(t *testing.T) {
+	results := []statusResult{{
+		target: target{owner: "foo", repository: "bar", sha: "abc"},
+		entry:  statusEntry{Context: "lint", State: "success"},
+	}}
+
+	gh := ghClient{dryRun: &dryRunClient{}}
+	require.Equal(t, map[string]string{"resolved_sha": "abc", "resolved_repository": "foo/bar"}, gh.statusOutputs(results))
+}
+
+func TestSetOutputs(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "output")
+	t.Setenv("GITHUB_OUTPUT", outputFile)
+
+	setOutputs(map[string]string{"status_id": "24601", "resolved_sha": "abc"})
+
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "resolved_sha<<")
+	require.Contains(t, string(data), "status_id<<")
+	require.Less(t, strings.Index(string(data), "resolved_sha<<"), strings.Index(string(data), "status_id<<"))
+}
+
+func TestSetOutputsOutsideActions(t *testing.T) {
+	t.Setenv("GITHUB_OUTPUT", "")
+	require.NotPanics(t, func() {
+		setOutputs(map[string]string{"status_id": "24601"})
+	})
+}
@@ -0,0 +1,191 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v53/github"
+)
+
+// The values of the provider input, which picks where the statuses are posted.
+const (
+	providerGitHub              = "github"
+	providerGitLab              = "gitlab"
+	providerBitbucket           = "bitbucket"
+	providerBitbucketDataCenter = "bitbucket-datacenter"
+	providerGitea               = "gitea"
+)
+
+// statusProvider creates commit statuses. The repositories service of the GitHub client is one. Other providers take
+// the same status and translate it to their own API, returning go-github errors for failed requests, so createStatus,
+// retries and error classification are shared by every provider.
+type statusProvider interface {
+	CreateStatus(context.Context, string, string, string, *github.RepoStatus) (*github.RepoStatus, *github.Response, error)
+}
+
+// statusRequester is implemented by providers whose requests differ from GitHub's, so a dry run shows the request the
+// provider would send.
+type statusRequester interface {
+	statusRequest(owner, repo, ref string, status *github.RepoStatus) dryRunRequest
+}
+
+// authTransport authenticates every request by setting a header.
+type authTransport struct {
+	base   http.RoundTripper
+	header string
+	value  string
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = cloneRequest(req)
+	req.Header.Set(t.header, t.value)
+	return t.base.RoundTrip(req)
+}
+
+// doJSON sends the request with a JSON body to the API and decodes the response into out, unless it is nil or the
+// response has no body. Failed requests are checked the same way as GitHub's, so they are retried and classified the
+// same way.
+func doJSON(ctx context.Context, client *http.Client, apiURL string, r dryRunRequest, out any) (*github.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, r.Method, apiURL+"/"+r.Path, strings.NewReader(marshalJSON(r.Body)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	httpResp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	resp := &github.Response{Response: httpResp}
+	if err := github.CheckResponse(httpResp); err != nil {
+		return resp, err
+	}
+	if out == nil {
+		return resp, nil
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return resp, fmt.Errorf("unable to read the response from %s: %w", r.Path, err)
+	}
+	return resp, nil
+}
+
+// isGitHub reports whether the statuses are posted to GitHub.
+func (in input) isGitHub() bool {
+	return in.provider == "" || in.provider == providerGitHub
+}
+
+// isBitbucket reports whether the statuses are posted to Bitbucket Cloud or Data Center.
+func (in input) isBitbucket() bool {
+	return in.provider == providerBitbucket || in.provider == providerBitbucketDataCenter
+}
+
+// convertState validates the state and converts it to a state of the provider.
+func (in input) convertState(state string) (string, error) {
+	switch in.provider {
+	case providerGitLab:
+		return convertActionStateToGitLabState(state, in.stateMap)
+	case providerBitbucket, providerBitbucketDataCenter:
+		return convertActionStateToBitbucketState(state, in.stateMap, in.provider == providerBitbucketDataCenter)
+	case providerGitea:
+		return convertActionStateToGiteaState(state, in.stateMap)
+	}
+	return convertActionStateToRepoStatusState(state, in.stateMap)
+}
+
+// statusStates are the states state_map can map to with the provider. Every provider takes the GitHub commit status
+// states as well as its own, lower cased the same as the mappings.
+func (in input) statusStates() []string {
+	switch in.provider {
+	case providerGitLab:
+		return append(commitStatusStates, "running", "failed", "canceled", "skipped")
+	case providerBitbucket, providerBitbucketDataCenter:
+		return append(commitStatusStates, "inprogress", "successful", "failed", "stopped")
+	case providerGitea:
+		return append(commitStatusStates, "warning")
+	}
+	return commitStatusStates
+}
+
+// statusPermission is the permission a token needs to post statuses to the provider.
+func (in input) statusPermission() string {
+	switch in.provider {
+	case providerGitLab:
+		return permissionGitLabAPI
+	case providerBitbucket:
+		return permissionBitbucket
+	case providerBitbucketDataCenter:
+		return permissionBitbucketDataCenter
+	case providerGitea:
+		return permissionGitea
+	}
+	return permissionStatuses
+}
+
+// validateProvider checks that the provider is known and that the inputs only use what it supports. Check runs,
+// combined statuses, workflow jobs and GitHub Apps only exist on GitHub.
+func validateProvider(in input) []error {
+	var errs []error
+	switch in.provider {
+	case "", providerGitHub:
+	case providerGitLab, providerBitbucket, providerBitbucketDataCenter, providerGitea:
+		unsupported := func(name string, used bool) {
+			if used {
+				errs = append(errs, fmt.Errorf("%s is not supported with provider %s", name, in.provider))
+			}
+		}
+		unsupported("mode "+modeCheckRun, in.mode == modeCheckRun)
+		unsupported("command "+in.subcommand, in.subcommand != "" && in.subcommand != commandSet)
+		unsupported("lifecycle", in.lifecycle)
+		unsupported("idempotent", in.idempotent)
+		unsupported("app_id", in.appID != 0)
+	default:
+		return []error{fmt.Errorf("provider value not supported: %s", in.provider)}
+	}
+
+	if in.pipelineID != 0 && in.provider != providerGitLab {
+		errs = append(errs, fmt.Errorf("pipeline_id is only supported with provider %s", providerGitLab))
+	}
+	if in.username != "" && !in.isBitbucket() {
+		errs = append(errs, fmt.Errorf("username is only supported with provider %s or %s", providerBitbucket, providerBitbucketDataCenter))
+	}
+	// Data Center and Gitea are always self-hosted
+	if (in.provider == providerBitbucketDataCenter || in.provider == providerGitea) && in.apiURL == "" {
+		errs = append(errs, fmt.Errorf("api_url is required with provider %s", in.provider))
+	}
+	if in.isBitbucket() {
+		errs = append(errs, validateBitbucketStatuses(in)...)
+	}
+	return errs
+}
+
+// validateBitbucketStatuses checks that every status has the key and URL Bitbucket requires. The URL falls back to the
+// workflow run, which only exists in GitHub Actions.
+func validateBitbucketStatuses(in input) []error {
+	var errs []error
+	for i, entry := range in.statusEntries() {
+		name := func(field string) string {
+			if len(in.statuses) > 0 {
+				return fmt.Sprintf("statuses[%d]: %s", i, field)
+			}
+			return field
+		}
+		if entry.Context == "" {
+			errs = append(errs, fmt.Errorf("%s is required with provider %s", name("context"), in.provider))
+		}
+		if entry.DetailsURL == "" && githubRunURL() == "" {
+			errs = append(errs, fmt.Errorf("%s is required with provider %s outside of GitHub Actions", name("details_url"), in.provider))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,174 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateProvider(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ID", "")
+
+	cases := []struct {
+		name     string
+		inputs   input
+		expected []string
+	}{
+		{
+			name:   "github_is_the_default",
+			inputs: input{mode: modeCheckRun, subcommand: commandWait},
+		},
+		{
+			name:     "pipeline_id_needs_gitlab",
+			inputs:   input{provider: providerGitHub, pipelineID: 7},
+			expected: []string{"pipeline_id is only supported with provider gitlab"},
+		},
+		{
+			name:   "gitlab_statuses",
+			inputs: input{provider: providerGitLab, subcommand: commandSet, pipelineID: 7},
+		},
+		{
+			name: "gitlab_without_github_features",
+			inputs: input{
+				provider:   providerGitLab,
+				mode:       modeCheckRun,
+				subcommand: commandRollup,
+				lifecycle:  true,
+				idempotent: true,
+				appID:      1,
+			},
+			expected: []string{
+				"mode check-run is not supported with provider gitlab",
+				"command rollup is not supported with provider gitlab",
+				"lifecycle is not supported with provider gitlab",
+				"idempotent is not supported with provider gitlab",
+				"app_id is not supported with provider gitlab",
+			},
+		},
+		{
+			name:   "bitbucket_app_password",
+			inputs: input{provider: providerBitbucket, username: "some-user", context: "ci", detailsURL: "https://some-url"},
+		},
+		{
+			name:   "bitbucket_needs_key_and_url",
+			inputs: input{provider: providerBitbucket},
+			expected: []string{
+				"context is required with provider bitbucket",
+				"details_url is required with provider bitbucket outside of GitHub Actions",
+			},
+		},
+		{
+			name:     "bitbucket_statuses_need_key_and_url",
+			inputs:   input{provider: providerBitbucket, statuses: []statusEntry{{Context: "ci", DetailsURL: "https://some-url"}, {Context: "lint"}}},
+			expected: []string{"statuses[1]: details_url is required with provider bitbucket outside of GitHub Actions"},
+		},
+		{
+			name:     "username_needs_bitbucket",
+			inputs:   input{provider: providerGitLab, username: "some-user"},
+			expected: []string{"username is only supported with provider bitbucket or bitbucket-datacenter"},
+		},
+		{
+			name:     "bitbucket_datacenter_needs_api_url",
+			inputs:   input{provider: providerBitbucketDataCenter, idempotent: true, context: "ci", detailsURL: "https://some-url"},
+			expected: []string{"idempotent is not supported with provider bitbucket-datacenter", "api_url is required with provider bitbucket-datacenter"},
+		},
+		{
+			name:     "gitea_needs_api_url",
+			inputs:   input{provider: providerGitea, subcommand: commandWait},
+			expected: []string{"command wait is not supported with provider gitea", "api_url is required with provider gitea"},
+		},
+		{
+			name:     "unknown_provider",
+			inputs:   input{provider: "foo"},
+			expected: []string{"provider value not supported: foo"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var errs []string
+			for _, err := range validateProvider(c.inputs) {
+				errs = append(errs, err.Error())
+			}
+			require.Equal(t, c.expected, errs)
+		})
+	}
+}
+
+func TestConvertStateForProvider(t *testing.T) {
+	state, err := input{}.convertState("cancelled")
+	require.NoError(t, err)
+	require.Equal(t, "error", state)
+
+	state, err = input{provider: providerGitLab}.convertState("cancelled")
+	require.NoError(t, err)
+	require.Equal(t, "canceled", state)
+
+	state, err = input{provider: providerBitbucket}.convertState("cancelled")
+	require.NoError(t, err)
+	require.Equal(t, "STOPPED", state)
+
+	state, err = input{provider: providerGitea}.convertState("neutral")
+	require.NoError(t, err)
+	require.Equal(t, "warning", state)
+}
+
+func TestResolveRefNeedsFullSHAWithoutGitHub(t *testing.T) {
+	gh := ghClient{input: input{provider: providerGitLab, owner: "group", repository: "project", sha: "main"}}
+	require.EqualError(t, gh.resolveRefs(context.Background()), "main is not a full commit SHA, which provider gitlab needs")
+}
+
+func TestDryRunWithGitLab(t *testing.T) {
+	in := input{provider: providerGitLab, owner: "group", repository: "project", sha: "abc", state: "running", context: "ci"}
+	gh := ghClient{client: newGitLabClient(in), input: in}
+	gh.enableDryRun()
+	_, err := gh.publish(context.Background())
+	require.NoError(t, err)
+	require.JSONEq(t, `[{
+		"method": "POST",
+		"path": "projects/group%2Fproject/statuses/abc",
+		"owner": "group",
+		"repository": "project",
+		"sha": "abc",
+		"body": {"state": "running", "name": "ci"}
+	}]`, gh.dryRun.json())
+}
+
+// recordedRequest is the request a test server received.
+type recordedRequest struct {
+	path   string
+	header http.Header
+	body   map[string]any
+}
+
+// newRecordingServer starts a server that answers with the status code and response and records the last request it
+// received. The handler runs on the goroutine of the server, so the request is read with the returned function once
+// the client is done.
+func newRecordingServer(t *testing.T, statusCode int, response string) (*httptest.Server, func() recordedRequest) {
+	var mu sync.Mutex
+	var recorded recordedRequest
+	var decodeErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		recorded = recordedRequest{path: r.URL.EscapedPath(), header: r.Header.Clone()}
+		decodeErr = json.NewDecoder(r.Body).Decode(&recorded.body)
+		mu.Unlock()
+		w.WriteHeader(statusCode)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() recordedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		require.NoError(t, decodeErr)
+		return recorded
+	}
+}
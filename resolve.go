@@ -0,0 +1,183 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v53/github"
+)
+
+// pullRequestRefPrefix marks a ref as a pull request number, for example pr:123.
+const pullRequestRefPrefix = "pr:"
+
+// maxTagDepth is how many tags pointing at tags are followed before giving up.
+const maxTagDepth = 5
+
+// fullSHAPattern matches a full SHA-1 or SHA-256 commit SHA, which is used as it is.
+var fullSHAPattern = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// shortSHAPattern matches an abbreviated commit SHA.
+var shortSHAPattern = regexp.MustCompile(`^[0-9a-f]{4,39}$`)
+
+type ghGitClient interface {
+	GetRef(context.Context, string, string, string) (*github.Reference, *github.Response, error)
+	GetTag(context.Context, string, string, string) (*github.Tag, *github.Response, error)
+}
+
+type ghPullsClient interface {
+	Get(context.Context, string, string, int) (*github.PullRequest, *github.Response, error)
+}
+
+type ghCommitsClient interface {
+	GetCommitSHA1(context.Context, string, string, string, string) (string, *github.Response, error)
+	GetCombinedStatus(context.Context, string, string, string, *github.ListOptions) (*github.CombinedStatus, *github.Response, error)
+}
+
+// resolveRefs replaces the refs of the inputs and targets with the commit SHAs they point at, so the sha input and
+// targets can name a branch, a tag, a pull request or a short SHA. Each ref is only resolved once. The templates are
+// rendered again afterwards so .SHA is the commit rather than the ref.
+func (gh *ghClient) resolveRefs(ctx context.Context) error {
+	resolved := map[target]string{}
+	resolve := func(t target) (string, error) {
+		if sha, ok := resolved[t]; ok {
+			return sha, nil
+		}
+		sha, err := gh.resolveRef(ctx, t)
+		if err != nil {
+			return "", err
+		}
+		if sha != t.sha {
+			action.Infof("Resolved %s to %s", t, sha)
+		}
+		resolved[t] = sha
+		return sha, nil
+	}
+
+	sha, err := resolve(gh.input.defaultTarget())
+	if err != nil {
+		return err
+	}
+	gh.input.sha = sha
+
+	for i, t := range gh.input.targets {
+		sha, err := resolve(t)
+		if err != nil {
+			return err
+		}
+		gh.input.targets[i].sha = sha
+	}
+
+	// The templates were rendered with the refs, so render them again with the commits they point at
+	gh.input, err = gh.input.renderTemplates()
+	if err != nil {
+		return err
+	}
+	gh.input, err = gh.input.fitDescriptions()
+	return err
+}
+
+// resolveRef returns the commit SHA the ref of the target points at. Tags are looked up before branches, the same as
+// git does, and short SHAs last.
+func (gh *ghClient) resolveRef(ctx context.Context, t target) (string, error) {
+	ref := t.sha
+	if fullSHAPattern.MatchString(ref) {
+		return ref, nil
+	}
+	// Refs are resolved with the GitHub API, which other providers cannot use
+	if !gh.input.isGitHub() {
+		return "", fmt.Errorf("%s is not a full commit SHA, which provider %s needs", ref, gh.input.provider)
+	}
+
+	if strings.HasPrefix(ref, pullRequestRefPrefix) {
+		return gh.resolvePullRequest(ctx, t, strings.TrimPrefix(ref, pullRequestRefPrefix))
+	}
+
+	refs := []string{"tags/" + ref, "heads/" + ref}
+	if strings.HasPrefix(ref, "refs/") {
+		refs = []string{strings.TrimPrefix(ref, "refs/")}
+	}
+	for _, name := range refs {
+		sha, err := gh.resolveGitRef(ctx, t, name)
+		if errors.Is(err, errNotFound) {
+			continue
+		}
+		return sha, err
+	}
+
+	if shortSHAPattern.MatchString(ref) {
+		var sha string
+		err := gh.withRetry(ctx, t, permissionContents, func(ctx context.Context) (*github.Response, error) {
+			var resp *github.Response
+			var err error
+			sha, resp, err = gh.commits.GetCommitSHA1(ctx, t.owner, t.repository, ref, "")
+			return resp, err
+		})
+		// An unknown SHA is rejected as unprocessable rather than not found
+		if err == nil || !(errors.Is(err, errNotFound) || errors.Is(err, errInvalid)) {
+			return sha, err
+		}
+	}
+
+	return "", fmt.Errorf("%s is not a commit, branch, tag or pull request in %s/%s", ref, t.owner, t.repository)
+}
+
+// resolvePullRequest returns the head commit of the pull request.
+func (gh *ghClient) resolvePullRequest(ctx context.Context, t target, number string) (string, error) {
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 {
+		return "", fmt.Errorf("pull request ref is not in the form pr:<number>: %s", t.sha)
+	}
+
+	var pr *github.PullRequest
+	err = gh.withRetry(ctx, t, permissionPullRequests, func(ctx context.Context) (*github.Response, error) {
+		var resp *github.Response
+		var err error
+		pr, resp, err = gh.pulls.Get(ctx, t.owner, t.repository, n)
+		return resp, err
+	})
+	if err != nil {
+		return "", err
+	}
+	return pr.GetHead().GetSHA(), nil
+}
+
+// resolveGitRef returns the commit the git ref points at. Annotated tags point at a tag object rather than a commit,
+// so those are followed to the commit.
+func (gh *ghClient) resolveGitRef(ctx context.Context, t target, name string) (string, error) {
+	var ref *github.Reference
+	err := gh.withRetry(ctx, t, permissionContents, func(ctx context.Context) (*github.Response, error) {
+		var resp *github.Response
+		var err error
+		ref, resp, err = gh.git.GetRef(ctx, t.owner, t.repository, name)
+		return resp, err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	object := ref.GetObject()
+	for depth := 0; object.GetType() == "tag"; depth++ {
+		if depth == maxTagDepth {
+			return "", fmt.Errorf("tag %s points at more than %d other tags", name, maxTagDepth)
+		}
+		var tag *github.Tag
+		err := gh.withRetry(ctx, t, permissionContents, func(ctx context.Context) (*github.Response, error) {
+			var resp *github.Response
+			var err error
+			tag, resp, err = gh.git.GetTag(ctx, t.owner, t.repository, object.GetSHA())
+			return resp, err
+		})
+		if err != nil {
+			return "", err
+		}
+		object = tag.GetObject()
+	}
+	return object.GetSHA(), nil
+}
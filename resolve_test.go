@@ -0,0 +1,170 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/stretchr/testify/require"
+)
+
+const resolvedSHA = "0123456789abcdef0123456789abcdef01234567"
+
+func TestResolveRef(t *testing.T) {
+	cases := []struct {
+		name        string
+		ref         string
+		expected    string
+		expectError string
+	}{
+		{
+			name:     "full_sha",
+			ref:      "fedcba9876543210fedcba9876543210fedcba98",
+			expected: "fedcba9876543210fedcba9876543210fedcba98",
+		},
+		{
+			name:     "branch",
+			ref:      "main",
+			expected: resolvedSHA,
+		},
+		{
+			name:     "lightweight_tag",
+			ref:      "v1.0.0",
+			expected: resolvedSHA,
+		},
+		{
+			name:     "annotated_tag",
+			ref:      "v2.0.0",
+			expected: resolvedSHA,
+		},
+		{
+			name:     "full_ref",
+			ref:      "refs/heads/main",
+			expected: resolvedSHA,
+		},
+		{
+			name:     "pull_request",
+			ref:      "pr:123",
+			expected: resolvedSHA,
+		},
+		{
+			name:     "short_sha",
+			ref:      "0123456",
+			expected: resolvedSHA,
+		},
+		{
+			name:        "error_unknown_ref",
+			ref:         "abcdef1",
+			expectError: "abcdef1 is not a commit, branch, tag or pull request in some-owner/some-repo",
+		},
+		{
+			name:        "error_invalid_pull_request",
+			ref:         "pr:foo",
+			expectError: "pull request ref is not in the form pr:<number>: pr:foo",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mock := &mockghRefsClient{}
+			gh := ghClient{git: mock, pulls: mock, commits: mock}
+			got, err := gh.resolveRef(context.Background(), target{owner: "some-owner", repository: "some-repo", sha: c.ref})
+			if c.expectError != "" {
+				require.EqualError(t, err, c.expectError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, got)
+		})
+	}
+}
+
+func TestResolveRefs(t *testing.T) {
+	mock := &mockghRefsClient{}
+	gh := ghClient{
+		git:     mock,
+		pulls:   mock,
+		commits: mock,
+		input: input{
+			owner:      "some-owner",
+			repository: "some-repo",
+			sha:        "main",
+			targets: []target{
+				{owner: "some-owner", repository: "some-repo", sha: "main"},
+				{owner: "some-owner", repository: "other-repo", sha: resolvedSHA},
+			},
+		},
+	}
+	require.NoError(t, gh.resolveRefs(context.Background()))
+	require.Equal(t, resolvedSHA, gh.input.sha)
+	require.Equal(t, resolvedSHA, gh.input.targets[0].sha)
+	require.Equal(t, resolvedSHA, gh.input.targets[1].sha)
+	// main is looked up as a tag and then as a branch once for both uses
+	require.Equal(t, []string{"tags/main", "heads/main"}, mock.refs)
+}
+
+func TestResolveRefsRendersTemplates(t *testing.T) {
+	mock := &mockghRefsClient{}
+	gh := ghClient{
+		git:     mock,
+		pulls:   mock,
+		commits: mock,
+		input: input{
+			owner:      "some-owner",
+			repository: "some-repo",
+			sha:        "main",
+			// Rendered from the ref when the inputs were read
+			template:            true,
+			description:         "sha main",
+			descriptionTemplate: "sha {{.SHA}}",
+		},
+	}
+	require.NoError(t, gh.resolveRefs(context.Background()))
+	require.Equal(t, "sha "+resolvedSHA, gh.input.description)
+}
+
+// mockghRefsClient knows the main branch, the lightweight tag v1.0.0, the annotated tag v2.0.0, pull request 123 and
+// the short SHA 0123456.
+type mockghRefsClient struct {
+	refs []string
+}
+
+func (m *mockghRefsClient) GetRef(_ context.Context, _, _, ref string) (*github.Reference, *github.Response, error) {
+	m.refs = append(m.refs, ref)
+	switch ref {
+	case "heads/main", "tags/v1.0.0":
+		return &github.Reference{Object: &github.GitObject{Type: github.String("commit"), SHA: github.String(resolvedSHA)}}, nil, nil
+	case "tags/v2.0.0":
+		return &github.Reference{Object: &github.GitObject{Type: github.String("tag"), SHA: github.String("tag-sha")}}, nil, nil
+	default:
+		return nil, nil, newErrorResponse(http.StatusNotFound, "Not Found")
+	}
+}
+
+func (m *mockghRefsClient) GetTag(_ context.Context, _, _, sha string) (*github.Tag, *github.Response, error) {
+	if sha != "tag-sha" {
+		return nil, nil, newErrorResponse(http.StatusNotFound, "Not Found")
+	}
+	return &github.Tag{Object: &github.GitObject{Type: github.String("commit"), SHA: github.String(resolvedSHA)}}, nil, nil
+}
+
+func (m *mockghRefsClient) Get(_ context.Context, _, _ string, number int) (*github.PullRequest, *github.Response, error) {
+	if number != 123 {
+		return nil, nil, newErrorResponse(http.StatusNotFound, "Not Found")
+	}
+	return &github.PullRequest{Head: &github.PullRequestBranch{SHA: github.String(resolvedSHA)}}, nil, nil
+}
+
+func (m *mockghRefsClient) GetCommitSHA1(_ context.Context, _, _, ref, _ string) (string, *github.Response, error) {
+	if ref != "0123456" {
+		return "", nil, newErrorResponse(http.StatusUnprocessableEntity, "No commit found for SHA: "+ref)
+	}
+	return resolvedSHA, nil, nil
+}
+
+func (m *mockghRefsClient) GetCombinedStatus(_ context.Context, _, _, _ string, _ *github.ListOptions) (*github.CombinedStatus, *github.Response, error) {
+	return &github.CombinedStatus{}, nil, nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/sethvargo/go-retry"
+)
+
+// defaultMaxRateLimitWait is the longest a retry waits for a rate limit to reset when max_rate_limit_wait is not set.
+const defaultMaxRateLimitWait = 5 * time.Minute
+
+// defaultSecondaryRateLimitWait is how long to wait for a secondary rate limit when GitHub does not say. GitHub asks
+// clients to wait at least a minute.
+const defaultSecondaryRateLimitWait = time.Minute
+
+// withRetry calls the GitHub API with a fibonacci backoff. When GitHub reports a rate limit the call sleeps until the
+// reported reset time instead, as long as that is within the maximum wait, and is made again straight away without
+// using up a retry. Errors that will not succeed when retried
+// are returned straight away as an apiError that explains the missing permission or bad input on the target. The
+// remaining quota is logged after every call.
+func (gh *ghClient) withRetry(ctx context.Context, t target, permission string, call func(context.Context) (*github.Response, error)) error {
+	// Do a fibonacci backoff 1s -> 1s -> 2s -> 3s -> 5s -> 8s
+	return retry.Do(ctx, retry.WithMaxRetries(gh.maxConnectionRetries, retry.NewFibonacci(1*time.Second)), func(ctx context.Context) error {
+		for {
+			resp, err := call(ctx)
+			logRateLimit(resp)
+			if err == nil {
+				return nil
+			}
+
+			if wait, limited := rateLimitWait(err, resp, time.Now()); limited {
+				if wait > gh.input.rateLimitMaxWait() {
+					return fmt.Errorf("rate limit resets in %s which is longer than max_rate_limit_wait %s: %w", wait.Round(time.Second), gh.input.rateLimitMaxWait(), err)
+				}
+				// A limit that already reset is retried with the backoff, so a server that keeps reporting it
+				// cannot keep the call going forever
+				if wait == 0 {
+					return retry.RetryableError(err)
+				}
+				action.Warningf("Rate limited by GitHub, waiting %s before retrying", wait.Round(time.Second))
+				if err := gh.wait(ctx, wait); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if apiErr := classifyError(err, t, permission); apiErr != nil {
+				return apiErr
+			}
+
+			// This marks the error as retryable
+			return retry.RetryableError(err)
+		}
+	})
+}
+
+// wait sleeps for the duration or until the context is done.
+func (gh *ghClient) wait(ctx context.Context, d time.Duration) error {
+	if gh.sleep != nil {
+		return gh.sleep(ctx, d)
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// rateLimitWait returns how long to wait before retrying when the error is caused by a rate limit. Primary rate limits
+// wait until the reset time and secondary rate limits wait for the Retry-After header. Responses that go-github does
+// not recognize, like a 429, fall back to the Retry-After and X-RateLimit-Reset headers.
+func rateLimitWait(err error, resp *github.Response, now time.Time) (time.Duration, bool) {
+	var rateLimitErr *github.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return untilReset(rateLimitErr.Rate.Reset.Time, now), true
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		if abuseErr.RetryAfter != nil {
+			return *abuseErr.RetryAfter, true
+		}
+		return defaultSecondaryRateLimitWait, true
+	}
+
+	if resp == nil || resp.Response == nil {
+		return 0, false
+	}
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	limited := resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0")
+	if !limited {
+		return 0, false
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		return untilReset(time.Unix(reset, 0), now), true
+	}
+	return defaultSecondaryRateLimitWait, true
+}
+
+// untilReset returns the time until the rate limit resets, with a second added because the reset time is rounded down.
+func untilReset(reset, now time.Time) time.Duration {
+	wait := reset.Sub(now) + time.Second
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// logRateLimit logs the remaining quota from the rate limit headers of the response.
+func logRateLimit(resp *github.Response) {
+	if resp == nil || resp.Rate.Limit == 0 {
+		return
+	}
+	action.Infof("Rate limit: %d/%d remaining, resets at %s", resp.Rate.Remaining, resp.Rate.Limit, resp.Rate.Reset.Format(time.RFC3339))
+}
+
+// rateLimitMaxWait returns the longest a retry may wait for a rate limit to reset.
+func (in input) rateLimitMaxWait() time.Duration {
+	if in.maxRateLimitWait == 0 {
+		return defaultMaxRateLimitWait
+	}
+	return in.maxRateLimitWait
+}
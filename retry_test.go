@@ -0,0 +1,153 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitWait(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	retryAfter := 30 * time.Second
+
+	cases := []struct {
+		name          string
+		err           error
+		resp          *github.Response
+		expectedWait  time.Duration
+		expectLimited bool
+	}{
+		{
+			name:          "primary_rate_limit",
+			err:           &github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: now.Add(time.Minute)}}},
+			expectedWait:  time.Minute + time.Second,
+			expectLimited: true,
+		},
+		{
+			name:          "secondary_rate_limit_with_retry_after",
+			err:           &github.AbuseRateLimitError{RetryAfter: &retryAfter},
+			expectedWait:  retryAfter,
+			expectLimited: true,
+		},
+		{
+			name:          "secondary_rate_limit_without_retry_after",
+			err:           &github.AbuseRateLimitError{},
+			expectedWait:  defaultSecondaryRateLimitWait,
+			expectLimited: true,
+		},
+		{
+			name:          "retry_after_header",
+			err:           errors.New("some-error"),
+			resp:          newResponse(http.StatusTooManyRequests, map[string]string{"Retry-After": "12"}),
+			expectedWait:  12 * time.Second,
+			expectLimited: true,
+		},
+		{
+			name: "rate_limit_reset_header",
+			err:  errors.New("some-error"),
+			resp: newResponse(http.StatusForbidden, map[string]string{
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     strconv.FormatInt(now.Add(2*time.Minute).Unix(), 10),
+			}),
+			expectedWait:  2*time.Minute + time.Second,
+			expectLimited: true,
+		},
+		{
+			name:          "server_error_is_not_rate_limited",
+			err:           errors.New("some-error"),
+			resp:          newResponse(http.StatusBadGateway, nil),
+			expectLimited: false,
+		},
+		{
+			name:          "network_error_is_not_rate_limited",
+			err:           errors.New("some-error"),
+			expectLimited: false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			wait, limited := rateLimitWait(c.err, c.resp, now)
+			require.Equal(t, c.expectLimited, limited)
+			require.Equal(t, c.expectedWait, wait)
+		})
+	}
+}
+
+func TestWithRetryWaitsForRateLimit(t *testing.T) {
+	cases := []struct {
+		name          string
+		maxWait       time.Duration
+		limitedCalls  int
+		expectedCalls int
+		expectedWaits []time.Duration
+		expectError   string
+	}{
+		{
+			name:          "waits_for_reset",
+			limitedCalls:  1,
+			expectedCalls: 2,
+			expectedWaits: []time.Duration{90 * time.Second},
+		},
+		{
+			name:          "waits_do_not_use_up_retries",
+			limitedCalls:  5,
+			expectedCalls: 6,
+			expectedWaits: []time.Duration{90 * time.Second, 90 * time.Second, 90 * time.Second, 90 * time.Second, 90 * time.Second},
+		},
+		{
+			name:          "error_reset_longer_than_max_wait",
+			maxWait:       time.Minute,
+			limitedCalls:  1,
+			expectedCalls: 1,
+			expectError:   "longer than max_rate_limit_wait 1m0s",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var waits []time.Duration
+			gh := ghClient{
+				input:                input{maxRateLimitWait: c.maxWait},
+				maxConnectionRetries: uint64(3),
+				sleep: func(_ context.Context, d time.Duration) error {
+					waits = append(waits, d)
+					return nil
+				},
+			}
+
+			calls := 0
+			retryAfter := 90 * time.Second
+			err := gh.withRetry(context.Background(), target{}, permissionStatuses, func(context.Context) (*github.Response, error) {
+				calls++
+				if calls <= c.limitedCalls {
+					return nil, &github.AbuseRateLimitError{RetryAfter: &retryAfter}
+				}
+				return nil, nil
+			})
+			if c.expectError != "" {
+				require.ErrorContains(t, err, c.expectError)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, c.expectedCalls, calls)
+			require.Equal(t, c.expectedWaits, waits)
+		})
+	}
+}
+
+// newResponse creates a GitHub response with the status code and headers.
+func newResponse(statusCode int, headers map[string]string) *github.Response {
+	header := http.Header{}
+	for k, v := range headers {
+		header.Set(k, v)
+	}
+	return &github.Response{Response: &http.Response{StatusCode: statusCode, Header: header}}
+}
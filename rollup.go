@@ -0,0 +1,157 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+const rollupContextRequiredErr = "context is required with command rollup"
+const rollupStatusesErr = "statuses is not supported with command rollup"
+const rollupTargetsErr = "targets is not supported with command rollup"
+
+// statePrecedence ranks the states for a rollup, the highest ranked state of any context wins.
+var statePrecedence = map[string]int{
+	"success": 0,
+	"pending": 1,
+	"failure": 2,
+	"error":   3,
+}
+
+// rollupResult is the aggregate of the contexts that were rolled up.
+type rollupResult struct {
+	state    string
+	contexts []string
+	states   map[string]string
+}
+
+// rollup posts a status or check run for the context that aggregates the current statuses of the other contexts it covers.
+func (gh *ghClient) rollup(ctx context.Context) ([]statusResult, error) {
+	statuses, err := gh.combinedStatus(ctx, gh.input.defaultTarget())
+	if err != nil {
+		return nil, err
+	}
+	states := map[string]string{}
+	for statusContext, status := range statuses {
+		states[statusContext] = status.GetState()
+	}
+
+	result := computeRollup(gh.input.context, gh.input.contexts, states)
+	for _, statusContext := range result.contexts {
+		action.Infof("Rolling up %s: %s", statusContext, result.states[statusContext])
+	}
+
+	// The rollup state is already a commit status state, state_map must not turn an unfinished rollup into a success
+	in := gh.input
+	in.stateMap = nil
+	// A description from the inputs is kept, otherwise the counts describe the rollup
+	in, err = in.withState(result.state, 0, func(description string) string {
+		if description != "" {
+			return description
+		}
+		return result.description()
+	})
+	if err != nil {
+		return nil, err
+	}
+	in.stateMap = gh.input.stateMap
+	gh.input = in
+	return gh.publish(ctx)
+}
+
+// computeRollup aggregates the states of the contexts that match the patterns. A context matches when it or one of
+// its parents does, so ci/test/unit rolls up into ci/test. Without patterns every context below the rollup context is
+// used. A matching context with matching contexts below it is a rollup of those, such as ci/test next to ci/test/unit,
+// so only the contexts below it are counted. Without any matching context the rollup is pending.
+func computeRollup(rollupContext string, patterns []string, states map[string]string) rollupResult {
+	if len(patterns) == 0 {
+		patterns = []string{rollupContext + "/*"}
+	}
+
+	var matched []string
+	for statusContext := range states {
+		if statusContext != rollupContext && matchesContext(statusContext, patterns) {
+			matched = append(matched, statusContext)
+		}
+	}
+
+	result := rollupResult{state: "success", states: map[string]string{}}
+	for _, statusContext := range matched {
+		if hasChildContext(statusContext, matched) {
+			continue
+		}
+		state := states[statusContext]
+		result.contexts = append(result.contexts, statusContext)
+		result.states[statusContext] = state
+		if statePrecedence[state] > statePrecedence[result.state] {
+			result.state = state
+		}
+	}
+	sort.Strings(result.contexts)
+
+	if len(result.contexts) == 0 {
+		result.state = "pending"
+	}
+	return result
+}
+
+// hasChildContext reports whether one of the contexts is below the context.
+func hasChildContext(statusContext string, contexts []string) bool {
+	for _, c := range contexts {
+		if strings.HasPrefix(c, statusContext+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesContext reports whether the context or one of its parents matches one of the patterns.
+func matchesContext(statusContext string, patterns []string) bool {
+	for c := statusContext; c != "." && c != "/"; c = path.Dir(c) {
+		for _, pattern := range patterns {
+			// The patterns were validated with the inputs
+			if ok, _ := path.Match(pattern, c); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// description counts the contexts by state, for example "7/8 passed, 1 pending".
+func (r rollupResult) description() string {
+	if len(r.contexts) == 0 {
+		return "No statuses to roll up yet"
+	}
+
+	counts := map[string]int{}
+	for _, statusContext := range r.contexts {
+		counts[r.states[statusContext]]++
+	}
+	parts := []string{fmt.Sprintf("%d/%d passed", counts["success"], len(r.contexts))}
+	for _, s := range []struct{ state, label string }{
+		{"failure", "failed"},
+		{"error", "errored"},
+		{"pending", "pending"},
+	} {
+		if counts[s.state] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[s.state], s.label))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// validatePatterns checks that every pattern is a valid path.Match pattern.
+func validatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("contexts pattern is not valid: %s", pattern)
+		}
+	}
+	return nil
+}
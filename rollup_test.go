@@ -0,0 +1,132 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeRollup(t *testing.T) {
+	states := map[string]string{
+		"ci/all":       "pending",
+		"ci/lint":      "success",
+		"ci/test/unit": "success",
+		"ci/test/e2e":  "pending",
+		"deploy":       "failure",
+	}
+
+	cases := []struct {
+		name             string
+		rollupContext    string
+		patterns         []string
+		expectedState    string
+		expectedContexts []string
+	}{
+		{
+			name:             "pattern",
+			rollupContext:    "ci/all",
+			patterns:         []string{"ci/*"},
+			expectedState:    "pending",
+			expectedContexts: []string{"ci/lint", "ci/test/e2e", "ci/test/unit"},
+		},
+		{
+			name:             "children_of_the_rollup_context",
+			rollupContext:    "ci/test",
+			expectedState:    "pending",
+			expectedContexts: []string{"ci/test/e2e", "ci/test/unit"},
+		},
+		{
+			name:             "list",
+			rollupContext:    "ci/all",
+			patterns:         []string{"ci/lint", "deploy"},
+			expectedState:    "failure",
+			expectedContexts: []string{"ci/lint", "deploy"},
+		},
+		{
+			name:             "success",
+			rollupContext:    "ci/all",
+			patterns:         []string{"ci/lint", "ci/test/unit"},
+			expectedState:    "success",
+			expectedContexts: []string{"ci/lint", "ci/test/unit"},
+		},
+		{
+			name:          "no_matching_contexts",
+			rollupContext: "release",
+			expectedState: "pending",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := computeRollup(c.rollupContext, c.patterns, states)
+			require.Equal(t, c.expectedState, got.state)
+			require.Equal(t, c.expectedContexts, got.contexts)
+		})
+	}
+}
+
+func TestComputeRollupNestedContexts(t *testing.T) {
+	// ci/test is a rollup of the contexts below it, so it is not counted next to them
+	states := map[string]string{
+		"ci/lint":      "success",
+		"ci/test":      "failure",
+		"ci/test/unit": "success",
+		"ci/test/e2e":  "success",
+	}
+	got := computeRollup("ci/all", []string{"ci/*"}, states)
+	require.Equal(t, "success", got.state)
+	require.Equal(t, []string{"ci/lint", "ci/test/e2e", "ci/test/unit"}, got.contexts)
+	require.Equal(t, "3/3 passed", got.description())
+
+	// Without the contexts below it ci/test counts on its own
+	got = computeRollup("ci/all", []string{"ci/*"}, map[string]string{"ci/lint": "success", "ci/test": "failure"})
+	require.Equal(t, "failure", got.state)
+	require.Equal(t, []string{"ci/lint", "ci/test"}, got.contexts)
+}
+
+func TestRollupPrecedence(t *testing.T) {
+	require.Equal(t, "error", computeRollup("all", []string{"*"}, map[string]string{"a": "failure", "b": "error", "c": "pending"}).state)
+	require.Equal(t, "failure", computeRollup("all", []string{"*"}, map[string]string{"a": "failure", "b": "pending", "c": "success"}).state)
+	require.Equal(t, "pending", computeRollup("all", []string{"*"}, map[string]string{"a": "success", "b": "pending"}).state)
+}
+
+func TestRollupDescription(t *testing.T) {
+	states := map[string]string{"a": "success", "b": "success", "c": "pending", "d": "failure"}
+	require.Equal(t, "2/4 passed, 1 failed, 1 pending", computeRollup("all", []string{"*"}, states).description())
+	require.Equal(t, "No statuses to roll up yet", computeRollup("all", []string{"*"}, nil).description())
+}
+
+func TestRollup(t *testing.T) {
+	mock := &mockStatusRecorder{}
+	gh := ghClient{
+		client: mock,
+		commits: &mockghCommitsClient{statuses: []*github.RepoStatus{
+			newRepoStatus("ci/unit", "success"),
+			newRepoStatus("ci/lint", "pending"),
+		}},
+		input: input{
+			context:    "ci/all",
+			contexts:   []string{"ci/*"},
+			owner:      "some-owner",
+			repository: "some-repo",
+			sha:        "some-sha",
+			subcommand: commandRollup,
+			stateMap:   map[string]string{"pending": "success"},
+		},
+	}
+
+	_, err := gh.rollup(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"ci/all"}, mock.contexts())
+	require.Equal(t, []string{"pending"}, mock.states)
+	require.Equal(t, []string{"1/2 passed, 1 pending"}, mock.descriptions)
+}
+
+func TestValidatePatterns(t *testing.T) {
+	require.NoError(t, validatePatterns([]string{"ci/*", "deploy"}))
+	require.EqualError(t, validatePatterns([]string{"ci/["}), "contexts pattern is not valid: ci/[")
+}
@@ -0,0 +1,108 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// defaultStateMap maps the job statuses of GitHub Actions and the statuses and conclusions of the Checks API to commit
+// status states.
+var defaultStateMap = map[string]string{
+	"success":         "success",
+	"error":           "error",
+	"failure":         "failure",
+	"pending":         "pending",
+	"cancel":          "error",
+	"cancelled":       "error",
+	"skipped":         "error",
+	"neutral":         "success",
+	"timed_out":       "failure",
+	"action_required": "failure",
+	"startup_failure": "failure",
+	"stale":           "error",
+	"queued":          "pending",
+	"in_progress":     "pending",
+	"waiting":         "pending",
+	"requested":       "pending",
+}
+
+// commitStatusStates are the states of a GitHub commit status.
+var commitStatusStates = []string{"success", "error", "failure", "pending"}
+
+// checkRunStates maps the same values to a check run status and conclusion. Values that are a check run conclusion
+// already are kept, except stale which only GitHub can set.
+var checkRunStates = map[string][2]string{
+	"pending":         {checkRunStatusInProgress, ""},
+	"queued":          {checkRunStatusQueued, ""},
+	"in_progress":     {checkRunStatusInProgress, ""},
+	"waiting":         {checkRunStatusInProgress, ""},
+	"requested":       {checkRunStatusInProgress, ""},
+	"success":         {checkRunStatusCompleted, "success"},
+	"error":           {checkRunStatusCompleted, "failure"},
+	"failure":         {checkRunStatusCompleted, "failure"},
+	"startup_failure": {checkRunStatusCompleted, "failure"},
+	"cancel":          {checkRunStatusCompleted, "cancelled"},
+	"cancelled":       {checkRunStatusCompleted, "cancelled"},
+	"skipped":         {checkRunStatusCompleted, "skipped"},
+	"neutral":         {checkRunStatusCompleted, "neutral"},
+	"timed_out":       {checkRunStatusCompleted, "timed_out"},
+	"action_required": {checkRunStatusCompleted, "action_required"},
+	"stale":           {checkRunStatusCompleted, "neutral"},
+}
+
+// parseStateMap parses a newline or comma separated list of `from=to` mappings that override defaultStateMap. The
+// values are matched without regard to case and every mapping must be to one of the states, the commit status states
+// of the provider.
+func parseStateMap(stateMap string, states []string) (map[string]string, error) {
+	// Accumulate errors
+	var errs *multierror.Error
+	var parsed map[string]string
+	for _, entry := range strings.FieldsFunc(stateMap, func(r rune) bool { return r == '\n' || r == ',' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		from, to, ok := strings.Cut(entry, "=")
+		from = strings.ToLower(strings.TrimSpace(from))
+		to = strings.ToLower(strings.TrimSpace(to))
+		if !ok || from == "" {
+			errs = multierror.Append(errs, fmt.Errorf("state_map entry is not in the form from=to: %s", entry))
+			continue
+		}
+		if _, ok := defaultStateMap[from]; !ok {
+			errs = multierror.Append(errs, fmt.Errorf("state_map maps a state that is not supported: %s", from))
+			continue
+		}
+		if !containsState(states, to) {
+			errs = multierror.Append(errs, fmt.Errorf("state_map maps %s to a state that is not a commit status state: %s", from, to))
+			continue
+		}
+		if parsed == nil {
+			parsed = map[string]string{}
+		}
+		parsed[from] = to
+	}
+
+	if errs != nil {
+		errs.ErrorFormat = joinErrors
+		return nil, errs
+	}
+
+	return parsed, nil
+}
+
+// containsState reports whether the state is one of the states.
+func containsState(states []string, state string) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
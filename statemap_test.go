@@ -0,0 +1,105 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStateMap(t *testing.T) {
+	cases := []struct {
+		name        string
+		stateMap    string
+		provider    string
+		expected    map[string]string
+		expectError string
+	}{
+		{
+			name:     "empty",
+			stateMap: "",
+			expected: nil,
+		},
+		{
+			name:     "comma_and_newline_separated",
+			stateMap: "skipped=success, Cancelled = FAILURE\ntimed_out=error\n",
+			expected: map[string]string{"skipped": "success", "cancelled": "failure", "timed_out": "error"},
+		},
+		{
+			name:     "gitlab_states",
+			stateMap: "skipped=skipped,cancelled=canceled,in_progress=running",
+			provider: providerGitLab,
+			expected: map[string]string{"skipped": "skipped", "cancelled": "canceled", "in_progress": "running"},
+		},
+		{
+			name:     "bitbucket_states",
+			stateMap: "skipped=STOPPED,neutral=success",
+			provider: providerBitbucket,
+			expected: map[string]string{"skipped": "stopped", "neutral": "success"},
+		},
+		{
+			name:     "gitea_states",
+			stateMap: "skipped=warning",
+			provider: providerGitea,
+			expected: map[string]string{"skipped": "warning"},
+		},
+		{
+			name:        "error_state_of_another_provider",
+			stateMap:    "skipped=warning",
+			provider:    providerGitLab,
+			expectError: "state_map maps skipped to a state that is not a commit status state: warning",
+		},
+		{
+			name:        "error_not_a_mapping",
+			stateMap:    "skipped",
+			expectError: "state_map entry is not in the form from=to: skipped",
+		},
+		{
+			name:        "error_unknown_state",
+			stateMap:    "foo=success",
+			expectError: "state_map maps a state that is not supported: foo",
+		},
+		{
+			name:        "error_all_problems_reported",
+			stateMap:    "skipped=neutral,foo=success",
+			expectError: "state_map maps skipped to a state that is not a commit status state: neutral, state_map maps a state that is not supported: foo",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := parseStateMap(c.stateMap, input{provider: c.provider}.statusStates())
+			if c.expectError != "" {
+				require.EqualError(t, err, c.expectError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, got)
+		})
+	}
+}
+
+func TestStateMapOverridesDefaults(t *testing.T) {
+	stateMap := map[string]string{"skipped": "success", "cancelled": "failure"}
+
+	state, err := convertActionStateToRepoStatusState("Skipped", stateMap)
+	require.NoError(t, err)
+	require.Equal(t, "success", state)
+
+	state, err = convertActionStateToRepoStatusState("cancel", stateMap)
+	require.NoError(t, err)
+	require.Equal(t, "error", state)
+
+	status, conclusion, err := convertActionStateToCheckRunStatus("cancelled", stateMap)
+	require.NoError(t, err)
+	require.Equal(t, checkRunStatusCompleted, status)
+	require.Equal(t, "failure", conclusion)
+}
+
+func TestEveryStateConvertsToACheckRun(t *testing.T) {
+	for state := range defaultStateMap {
+		_, _, err := convertActionStateToCheckRunStatus(state, nil)
+		require.NoError(t, err, state)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultContext = "default"
+
+// statusEntry is a single commit status to post.
+type statusEntry struct {
+	Context     string `yaml:"context"`
+	State       string `yaml:"state"`
+	Description string `yaml:"description"`
+	DetailsURL  string `yaml:"details_url"`
+}
+
+// statusEntries returns the statuses to post. When no list of statuses is set the single status from the inputs is
+// posted.
+func (in input) statusEntries() []statusEntry {
+	if len(in.statuses) > 0 {
+		return in.statuses
+	}
+	return []statusEntry{{
+		Context:     in.context,
+		State:       in.state,
+		Description: in.description,
+		DetailsURL:  in.detailsURL,
+	}}
+}
+
+// parseStatuses parses a YAML or JSON list of statuses and converts the state of every entry with convertState.
+func parseStatuses(statuses string, convertState func(string) (string, error)) ([]statusEntry, error) {
+	var entries []statusEntry
+	decoder := yaml.NewDecoder(strings.NewReader(statuses))
+	decoder.KnownFields(true)
+	err := decoder.Decode(&entries)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("unable to parse statuses: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("statuses must contain at least one status")
+	}
+
+	// Accumulate errors
+	var errs *multierror.Error
+	seen := map[string]bool{}
+	for i := range entries {
+		if entries[i].Context == "" {
+			entries[i].Context = defaultContext
+		}
+		// Statuses are posted at the same time, so two with the same context would race for the final state
+		if seen[entries[i].Context] {
+			errs = multierror.Append(errs, fmt.Errorf("statuses[%d]: context %s is repeated", i, entries[i].Context))
+		}
+		seen[entries[i].Context] = true
+		entries[i].State, err = convertState(entries[i].State)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("statuses[%d]: %w", i, err))
+		}
+	}
+
+	if errs != nil {
+		errs.ErrorFormat = joinErrors
+		return nil, errs
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,143 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatuses(t *testing.T) {
+	cases := []struct {
+		name        string
+		statuses    string
+		expected    []statusEntry
+		expectError string
+	}{
+		{
+			name: "yaml",
+			statuses: `
+- context: lint
+  state: pending
+  description: Linting
+- context: unit
+  state: cancelled
+  details_url: https://foo
+`,
+			expected: []statusEntry{
+				{Context: "lint", State: "pending", Description: "Linting"},
+				{Context: "unit", State: "error", DetailsURL: "https://foo"},
+			},
+		},
+		{
+			name:     "json",
+			statuses: `[{"context": "lint", "state": "success"}, {"state": "failure"}]`,
+			expected: []statusEntry{
+				{Context: "lint", State: "success"},
+				{Context: "default", State: "failure"},
+			},
+		},
+		{
+			name:        "error_repeated_context",
+			statuses:    `[{"context": "a", "state": "success"}, {"context": "a", "state": "failure"}, {"state": "success"}, {"state": "failure"}]`,
+			expectError: "statuses[1]: context a is repeated, statuses[3]: context default is repeated",
+		},
+		{
+			name:        "error_empty_list",
+			statuses:    "[]",
+			expectError: "at least one status",
+		},
+		{
+			name:        "error_unknown_field",
+			statuses:    `[{"context": "lint", "stat": "success"}]`,
+			expectError: "unable to parse statuses",
+		},
+		{
+			name:        "error_all_invalid_states_reported",
+			statuses:    `[{"context": "a", "state": "foo"}, {"context": "b", "state": "success"}, {"context": "c", "state": "bar"}]`,
+			expectError: "statuses[0]: state value not supported: foo, statuses[2]: state value not supported: bar",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := parseStatuses(c.statuses, input{}.convertState)
+			if c.expectError != "" {
+				require.ErrorContains(t, err, c.expectError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, got)
+		})
+	}
+}
+
+func TestCreateMultipleStatuses(t *testing.T) {
+	in := input{
+		token:      "some-token",
+		owner:      "some-owner",
+		repository: "some-repo",
+		sha:        "some-sha",
+		statuses: []statusEntry{
+			{Context: "lint", State: "pending"},
+			{Context: "unit", State: "pending"},
+			{Context: "e2e", State: "pending"},
+		},
+	}
+
+	mock := &mockStatusRecorder{failContexts: map[string]bool{"unit": true}}
+	gh := ghClient{client: mock, input: in, maxConnectionRetries: uint64(0)}
+	_, err := gh.createStatus(context.Background())
+	require.EqualError(t, err, "unit on some-owner/some-repo@some-sha: some-error")
+	require.Equal(t, []string{"e2e", "lint", "unit"}, mock.contexts())
+}
+
+// mockStatusRecorder records every status that is created and fails for the configured contexts.
+type mockStatusRecorder struct {
+	mu           sync.Mutex
+	created      []string
+	targets      []string
+	states       []string
+	descriptions []string
+	failContexts map[string]bool
+	failStates   map[string]bool
+}
+
+func (m *mockStatusRecorder) CreateStatus(_ context.Context, owner, repo, ref string, status *github.RepoStatus) (*github.RepoStatus, *github.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.created = append(m.created, status.GetContext())
+	m.states = append(m.states, status.GetState())
+	m.descriptions = append(m.descriptions, status.GetDescription())
+	m.targets = append(m.targets, target{owner: owner, repository: repo, sha: ref}.String())
+	if m.failContexts[status.GetContext()] || m.failStates[status.GetState()] {
+		return nil, nil, errors.New("some-error")
+	}
+	id := int64(len(m.created))
+	return &github.RepoStatus{ID: &id}, nil, nil
+}
+
+func (m *mockStatusRecorder) contexts() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	contexts := append([]string{}, m.created...)
+	sort.Strings(contexts)
+	return contexts
+}
+
+func (m *mockStatusRecorder) postedTargets() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	targets := append([]string{}, m.targets...)
+	sort.Strings(targets)
+	return targets
+}
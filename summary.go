@@ -0,0 +1,93 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// shortSHALength is the length SHAs are shortened to in links, the same as the GitHub UI.
+const shortSHALength = 7
+
+// stateEmoji is shown next to each state in the job summary, including the states of GitLab, Bitbucket and Gitea.
+var stateEmoji = map[string]string{
+	"success":    "✅",
+	"failure":    "❌",
+	"error":      "❗",
+	"pending":    "⏳",
+	"running":    "⏳",
+	"failed":     "❌",
+	"canceled":   "❗",
+	"skipped":    "⏭️",
+	"warning":    "⚠️",
+	"SUCCESSFUL": "✅",
+	"FAILED":     "❌",
+	"INPROGRESS": "⏳",
+	"STOPPED":    "❗",
+}
+
+// statusSummary renders the results as a Markdown table for the job summary. The heading and columns use the words of
+// the commit statuses or check runs that were posted.
+func (gh *ghClient) statusSummary(results []statusResult) string {
+	heading := "Commit statuses"
+	columns := "| Context | State | Description | Target | Commit |\n" +
+		"| ------- | ----- | ----------- | ------ | ------ |\n"
+	if gh.input.mode == modeCheckRun {
+		heading = "Check runs"
+		columns = "| Name | Status | Summary | Details | Commit |\n" +
+			"| ---- | ------ | ------- | ------- | ------ |\n"
+	}
+
+	var b strings.Builder
+	if gh.dryRun != nil {
+		fmt.Fprintf(&b, "### %s (dry run, nothing was posted)\n\n", heading)
+	} else {
+		fmt.Fprintf(&b, "### %s\n\n", heading)
+	}
+	b.WriteString(columns)
+	for _, r := range results {
+		state := fmt.Sprintf("%s %s", stateEmoji[r.entry.State], r.entry.State)
+		description := r.entry.Description
+		switch {
+		case gh.dryRun != nil:
+			state = "would post " + state
+		case r.skipped:
+			state += " (unchanged)"
+		}
+		if r.err != nil {
+			state = "🚫 not posted"
+			description = r.err.Error()
+		}
+
+		details := ""
+		if r.entry.DetailsURL != "" {
+			details = fmt.Sprintf("[details](%s)", r.entry.DetailsURL)
+		}
+
+		sha := r.target.sha
+		if len(sha) > shortSHALength {
+			sha = sha[:shortSHALength]
+		}
+		commit := fmt.Sprintf("[%s/%s@%s](%s)", r.target.owner, r.target.repository, sha, gh.commitURL(r.target))
+
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+			escapeTableCell(r.entry.Context), strings.TrimSpace(state), escapeTableCell(description), details, commit)
+	}
+	return b.String()
+}
+
+// escapeTableCell escapes text so it stays inside a single Markdown table cell.
+func escapeTableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// addStepSummary appends the Markdown to the job summary.
+func addStepSummary(markdown string) {
+	if !hasCommandFile("GITHUB_STEP_SUMMARY") {
+		return
+	}
+	action.AddStepSummary(markdown)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusSummary(t *testing.T) {
+	results := []statusResult{
+		{
+			target: target{owner: "foo", repository: "bar", sha: "0123456789abcdef"},
+			entry:  statusEntry{Context: "lint", State: "success", Description: "All good | no issues", DetailsURL: "https://foo"},
+			id:     1,
+		},
+		{
+			target: target{owner: "foo", repository: "bar", sha: "0123456789abcdef"},
+			entry:  statusEntry{Context: "unit", State: "pending"},
+			err:    errors.New("token lacks repo:status on foo/bar"),
+		},
+	}
+
+	expected := "### Commit statuses\n\n" +
+		"| Context | State | Description | Target | Commit |\n" +
+		"| ------- | ----- | ----------- | ------ | ------ |\n" +
+		"| lint | ✅ success | All good \\| no issues | [details](https://foo) | [foo/bar@0123456](https://github.com/foo/bar/commits/0123456789abcdef) |\n" +
+		"| unit | 🚫 not posted | token lacks repo:status on foo/bar |  | [foo/bar@0123456](https://github.com/foo/bar/commits/0123456789abcdef) |\n"
+
+	gh := ghClient{}
+	require.Equal(t, expected, gh.statusSummary(results))
+}
+
+func TestStatusSummaryDryRun(t *testing.T) {
+	results := []statusResult{{
+		target: target{owner: "foo", repository: "bar", sha: "0123456789abcdef"},
+		entry:  statusEntry{Context: "lint", State: "success"},
+	}}
+
+	expected := "### Commit statuses (dry run, nothing was posted)\n\n" +
+		"| Context | State | Description | Target | Commit |\n" +
+		"| ------- | ----- | ----------- | ------ | ------ |\n" +
+		"| lint | would post ✅ success |  |  | [foo/bar@0123456](https://github.com/foo/bar/commits/0123456789abcdef) |\n"
+
+	gh := ghClient{dryRun: &dryRunClient{}}
+	require.Equal(t, expected, gh.statusSummary(results))
+}
+
+func TestStatusSummaryCheckRun(t *testing.T) {
+	results := []statusResult{{
+		target: target{owner: "foo", repository: "bar", sha: "0123456789abcdef"},
+		entry:  statusEntry{Context: "build", State: "success", Description: "Built"},
+		id:     1,
+	}}
+
+	expected := "### Check runs\n\n" +
+		"| Name | Status | Summary | Details | Commit |\n" +
+		"| ---- | ------ | ------- | ------- | ------ |\n" +
+		"| build | ✅ success | Built |  | [foo/bar@0123456](https://github.com/foo/bar/commits/0123456789abcdef) |\n"
+
+	gh := ghClient{input: input{mode: modeCheckRun}}
+	require.Equal(t, expected, gh.statusSummary(results))
+}
+
+func TestAddStepSummary(t *testing.T) {
+	summaryFile := filepath.Join(t.TempDir(), "summary")
+	t.Setenv("GITHUB_STEP_SUMMARY", summaryFile)
+
+	addStepSummary("### Commit statuses")
+
+	data, err := os.ReadFile(summaryFile)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "### Commit statuses")
+
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	require.NotPanics(t, func() {
+		addStepSummary("### Commit statuses")
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// defaultMaxConcurrency is the number of statuses posted at the same time when max_concurrency is not set.
+const defaultMaxConcurrency = 4
+
+// target is a commit in a repository to post statuses to.
+type target struct {
+	owner      string
+	repository string
+	sha        string
+}
+
+// statusJob is a single status to post to a single target.
+type statusJob struct {
+	target target
+	entry  statusEntry
+}
+
+func (t target) String() string {
+	return fmt.Sprintf("%s/%s@%s", t.owner, t.repository, t.sha)
+}
+
+// statusTargets returns the targets to post statuses to. When no list of targets is set the owner, repository and SHA
+// from the inputs are used.
+func (in input) statusTargets() []target {
+	if len(in.targets) > 0 {
+		return in.targets
+	}
+	return []target{in.defaultTarget()}
+}
+
+// defaultTarget returns the commit from the owner, repository and SHA inputs.
+func (in input) defaultTarget() target {
+	return target{owner: in.owner, repository: in.repository, sha: in.sha}
+}
+
+// forTarget returns the inputs to post to the target with. Every target is a different commit, so the templates are
+// rendered again with its owner, repository and SHA.
+func (in input) forTarget(t target) (input, error) {
+	if !in.template || len(in.targets) == 0 {
+		return in, nil
+	}
+	in.owner, in.repository, in.sha = t.owner, t.repository, t.sha
+	in, err := in.renderTemplates()
+	if err != nil {
+		return input{}, err
+	}
+	if in.describe != nil {
+		return in.describeStatuses(), nil
+	}
+	return in.fitDescriptions()
+}
+
+// workers returns the number of workers needed to post the jobs, capped by max_concurrency.
+func (in input) workers(jobs int) int {
+	limit := in.maxConcurrency
+	if limit == 0 {
+		limit = defaultMaxConcurrency
+	}
+	if jobs < limit {
+		return jobs
+	}
+	return limit
+}
+
+// parseTargets parses a newline or comma separated list of `owner/repo@sha` targets. The SHA can be left out to use
+// the default SHA. With subgroups the owner can have several parts, such as a GitLab `group/subgroup/project`, and the
+// repository is the last one.
+func parseTargets(targets string, subgroups bool) ([]target, error) {
+	// Accumulate errors
+	var errs *multierror.Error
+	var parsed []target
+	seen := map[target]bool{}
+	for _, entry := range strings.FieldsFunc(targets, func(r rune) bool { return r == '\n' || r == ',' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		repo, sha, _ := strings.Cut(entry, "@")
+		owner, repository, ok := strings.Cut(repo, "/")
+		if subgroups {
+			if i := strings.LastIndex(repo, "/"); i >= 0 {
+				owner, repository = repo[:i], repo[i+1:]
+			}
+		}
+		if !ok || owner == "" || repository == "" || strings.Contains(repository, "/") {
+			errs = multierror.Append(errs, fmt.Errorf("target is not in the form owner/repo@sha: %s", entry))
+			continue
+		}
+		t := target{owner: owner, repository: repository, sha: sha}
+		// Statuses are posted at the same time, so the same commit twice would race for the final state
+		if seen[t] {
+			errs = multierror.Append(errs, fmt.Errorf("target is repeated: %s", entry))
+			continue
+		}
+		seen[t] = true
+		parsed = append(parsed, t)
+	}
+
+	if errs != nil {
+		errs.ErrorFormat = joinErrors
+		return nil, errs
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,139 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTargets(t *testing.T) {
+	cases := []struct {
+		name        string
+		targets     string
+		subgroups   bool
+		expected    []target
+		expectError string
+	}{
+		{
+			name:     "empty",
+			targets:  "",
+			expected: nil,
+		},
+		{
+			name:    "newline_and_comma_separated",
+			targets: "foo/bar@abc123\n foo/baz@def456, other/repo\n",
+			expected: []target{
+				{owner: "foo", repository: "bar", sha: "abc123"},
+				{owner: "foo", repository: "baz", sha: "def456"},
+				{owner: "other", repository: "repo"},
+			},
+		},
+		{
+			name:      "subgroups",
+			targets:   "group/sub/project@abc123,group/project",
+			subgroups: true,
+			expected: []target{
+				{owner: "group/sub", repository: "project", sha: "abc123"},
+				{owner: "group", repository: "project"},
+			},
+		},
+		{
+			name:        "error_subgroups_without_project",
+			targets:     "group/sub/@abc123",
+			subgroups:   true,
+			expectError: "target is not in the form owner/repo@sha: group/sub/@abc123",
+		},
+		{
+			name:        "error_repeated_target",
+			targets:     "foo/bar@abc123,foo/baz@abc123\nfoo/bar@abc123",
+			expectError: "target is repeated: foo/bar@abc123",
+		},
+		{
+			name:        "error_invalid_targets_reported",
+			targets:     "bar@abc123,foo/bar/baz@abc123",
+			expectError: "target is not in the form owner/repo@sha: bar@abc123, target is not in the form owner/repo@sha: foo/bar/baz@abc123",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := parseTargets(c.targets, c.subgroups)
+			if c.expectError != "" {
+				require.EqualError(t, err, c.expectError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, got)
+		})
+	}
+}
+
+func TestWorkers(t *testing.T) {
+	require.Equal(t, 2, input{}.workers(2))
+	require.Equal(t, defaultMaxConcurrency, input{}.workers(10))
+	require.Equal(t, 1, input{maxConcurrency: 1}.workers(10))
+}
+
+func TestSetInputDefaultsTargetSHA(t *testing.T) {
+	in, err := setInputDefaults(input{
+		owner:      "some-owner",
+		repository: "some-repo",
+		sha:        "some-sha",
+		targets: []target{
+			{owner: "foo", repository: "bar"},
+			{owner: "foo", repository: "baz", sha: "other-sha"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []target{
+		{owner: "foo", repository: "bar", sha: "some-sha"},
+		{owner: "foo", repository: "baz", sha: "other-sha"},
+	}, in.targets)
+}
+
+func TestCreateStatusOnTargets(t *testing.T) {
+	in := input{
+		token: "some-token",
+		targets: []target{
+			{owner: "foo", repository: "bar", sha: "abc"},
+			{owner: "foo", repository: "baz", sha: "def"},
+			{owner: "other", repository: "repo", sha: "123"},
+		},
+		statuses: []statusEntry{
+			{Context: "lint", State: "success"},
+			{Context: "unit", State: "success"},
+		},
+		maxConcurrency: 2,
+	}
+
+	mock := &mockStatusRecorder{failContexts: map[string]bool{}}
+	gh := ghClient{client: mock, input: in, maxConnectionRetries: uint64(0)}
+	_, err := gh.createStatus(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"lint", "lint", "lint", "unit", "unit", "unit"}, mock.contexts())
+	require.Equal(t, []string{"foo/bar@abc", "foo/bar@abc", "foo/baz@def", "foo/baz@def", "other/repo@123", "other/repo@123"}, mock.postedTargets())
+}
+
+func TestCreateStatusRendersTemplatesForEachTarget(t *testing.T) {
+	in := input{
+		token:               "some-token",
+		context:             "build",
+		state:               "success",
+		template:            true,
+		descriptionTemplate: "Built {{.Owner}}/{{.Repository}}@{{.SHA}}",
+		targets: []target{
+			{owner: "foo", repository: "bar", sha: "abc"},
+			{owner: "other", repository: "repo", sha: "123"},
+		},
+		maxConcurrency: 1,
+	}
+
+	mock := &mockStatusRecorder{failContexts: map[string]bool{}}
+	gh := ghClient{client: mock, input: in, maxConnectionRetries: uint64(0)}
+	_, err := gh.createStatus(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"Built foo/bar@abc", "Built other/repo@123"}, mock.descriptions)
+}
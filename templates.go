@@ -0,0 +1,146 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the functions templates can use besides the text/template builtins.
+var templateFuncs = template.FuncMap{
+	"title": titleCase,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// templateData is what the description and details URL templates are rendered with.
+type templateData struct {
+	Env        map[string]string
+	Owner      string
+	Repository string
+	SHA        string
+	State      string
+	Context    string
+	RunNumber  string
+	RunAttempt string
+	// Elapsed is how long the wrapped command or the job took, empty before it finished.
+	Elapsed string
+	Values  map[string]any
+}
+
+// renderTemplates renders the description and details URL of the inputs and of every status from their templates.
+// Statuses are rendered with their own state and context.
+func (in input) renderTemplates() (input, error) {
+	var err error
+	in.description, err = in.render("description", in.descriptionTemplate, in.state, in.context)
+	if err != nil {
+		return input{}, err
+	}
+	in.detailsURL, err = in.render("details_url", in.detailsURLTemplate, in.state, in.context)
+	if err != nil {
+		return input{}, err
+	}
+
+	statuses := make([]statusEntry, len(in.statuses))
+	for i, entry := range in.statuses {
+		tmpl := in.statusTemplates[i]
+		entry.Description, err = in.render(fmt.Sprintf("statuses[%d].description", i), tmpl.Description, entry.State, entry.Context)
+		if err != nil {
+			return input{}, err
+		}
+		entry.DetailsURL, err = in.render(fmt.Sprintf("statuses[%d].details_url", i), tmpl.DetailsURL, entry.State, entry.Context)
+		if err != nil {
+			return input{}, err
+		}
+		statuses[i] = entry
+	}
+	if len(statuses) > 0 {
+		in.statuses = statuses
+	}
+	return in, nil
+}
+
+// render renders a single template when templates are enabled. Unknown fields and missing keys of the environment or
+// values are errors rather than empty text.
+func (in input) render(name, text, state, statusContext string) (string, error) {
+	if !in.template || !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%s is not a valid template: %w", name, err)
+	}
+
+	if statusContext == "" {
+		statusContext = defaultContext
+	}
+	data := templateData{
+		Env:        templateEnv(),
+		Owner:      in.owner,
+		Repository: in.repository,
+		SHA:        in.sha,
+		State:      state,
+		Context:    statusContext,
+		RunNumber:  os.Getenv("GITHUB_RUN_NUMBER"),
+		RunAttempt: os.Getenv("GITHUB_RUN_ATTEMPT"),
+		Values:     in.templateValues,
+	}
+	if in.elapsed > 0 {
+		data.Elapsed = formatDuration(in.elapsed)
+	}
+	if data.Values == nil {
+		data.Values = map[string]any{}
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("unable to render %s: %w", name, err)
+	}
+	return b.String(), nil
+}
+
+// readTemplateValues reads the JSON object templates can use as .Values.
+func readTemplateValues(file string) (map[string]any, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read values_file: %w", err)
+	}
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("values_file is not a JSON object: %w", err)
+	}
+	return values, nil
+}
+
+// templateEnv returns the GITHUB_* and RUNNER_* variables of the environment as a map. The inputs and tokens are left
+// out, a description rendered from untrusted text must not be able to publish them.
+func templateEnv() map[string]string {
+	env := map[string]string{}
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(k, "GITHUB_") && !strings.HasPrefix(k, "RUNNER_") {
+			continue
+		}
+		if strings.HasSuffix(k, "_TOKEN") {
+			continue
+		}
+		env[k] = v
+	}
+	return env
+}
+
+// titleCase upper cases the first letter of every word.
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, word := range words {
+		r := []rune(word)
+		words[i] = strings.ToUpper(string(r[0])) + string(r[1:])
+	}
+	return strings.Join(words, " ")
+}
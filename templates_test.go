@@ -0,0 +1,139 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTemplates(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ATTEMPT", "2")
+	t.Setenv("GITHUB_RUN_NUMBER", "17")
+	t.Setenv("GITHUB_TOKEN", "some-token")
+	t.Setenv("INPUT_TOKEN", "some-token")
+
+	cases := []struct {
+		name        string
+		in          input
+		expected    string
+		expectError string
+	}{
+		{
+			name:     "plain_text",
+			in:       input{template: true, descriptionTemplate: "some-description"},
+			expected: "some-description",
+		},
+		{
+			name:     "fields_and_functions",
+			in:       input{template: true, descriptionTemplate: "{{.State | title}} in {{.Elapsed}} (attempt {{.Env.GITHUB_RUN_ATTEMPT}})", state: "success", elapsed: 90 * time.Second},
+			expected: "Success in 1m30s (attempt 2)",
+		},
+		{
+			name:     "inputs",
+			in:       input{template: true, descriptionTemplate: "{{.Context}} for {{.Owner}}/{{.Repository}}@{{.SHA}} run {{.RunNumber}}.{{.RunAttempt}}", context: "lint", owner: "some-owner", repository: "some-repo", sha: "some-sha"},
+			expected: "lint for some-owner/some-repo@some-sha run 17.2",
+		},
+		{
+			name:     "elapsed_empty_before_finishing",
+			in:       input{template: true, descriptionTemplate: "Done{{with .Elapsed}} in {{.}}{{end}}"},
+			expected: "Done",
+		},
+		{
+			name:     "values",
+			in:       input{template: true, descriptionTemplate: "Coverage {{.Values.coverage}}%", templateValues: map[string]any{"coverage": 87.5}},
+			expected: "Coverage 87.5%",
+		},
+		{
+			name:     "not_a_template_without_template",
+			in:       input{descriptionTemplate: "{{.State}} stays"},
+			expected: "{{.State}} stays",
+		},
+		{
+			name:        "error_input_not_in_env",
+			in:          input{template: true, descriptionTemplate: "{{.Env.INPUT_TOKEN}}"},
+			expectError: "map has no entry for key \"INPUT_TOKEN\"",
+		},
+		{
+			name:        "error_token_not_in_env",
+			in:          input{template: true, descriptionTemplate: "{{.Env.GITHUB_TOKEN}}"},
+			expectError: "map has no entry for key \"GITHUB_TOKEN\"",
+		},
+		{
+			name:        "error_unknown_field",
+			in:          input{template: true, descriptionTemplate: "{{.Foo}}"},
+			expectError: "unable to render description",
+		},
+		{
+			name:        "error_missing_env",
+			in:          input{template: true, descriptionTemplate: "{{.Env.SOME_UNSET_VARIABLE}}"},
+			expectError: "map has no entry for key \"SOME_UNSET_VARIABLE\"",
+		},
+		{
+			name:        "error_missing_value",
+			in:          input{template: true, descriptionTemplate: "{{.Values.coverage}}"},
+			expectError: "map has no entry for key \"coverage\"",
+		},
+		{
+			name:        "error_invalid_template",
+			in:          input{template: true, descriptionTemplate: "{{.State"},
+			expectError: "description is not a valid template",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := c.in.renderTemplates()
+			if c.expectError != "" {
+				require.ErrorContains(t, err, c.expectError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, got.description)
+		})
+	}
+}
+
+func TestRenderStatusTemplates(t *testing.T) {
+	statuses := []statusEntry{
+		{Context: "unit", State: "success", Description: "{{.Context}} is {{.State}}", DetailsURL: "https://example.com/{{.Context}}"},
+		{Context: "lint", State: "failure", Description: "plain"},
+	}
+	in := input{template: true, statuses: statuses, statusTemplates: statuses}
+
+	got, err := in.renderTemplates()
+	require.NoError(t, err)
+	require.Equal(t, []statusEntry{
+		{Context: "unit", State: "success", Description: "unit is success", DetailsURL: "https://example.com/unit"},
+		{Context: "lint", State: "failure", Description: "plain"},
+	}, got.statuses)
+
+	// The templates are rendered again when the state changes
+	got, err = got.withState("pending", 0, nil)
+	require.NoError(t, err)
+	require.Equal(t, "unit is pending", got.statuses[0].Description)
+}
+
+func TestReadTemplateValues(t *testing.T) {
+	dir := t.TempDir()
+	valuesFile := filepath.Join(dir, "values.json")
+	require.NoError(t, os.WriteFile(valuesFile, []byte(`{"coverage": 87.5, "report": {"url": "https://example.com"}}`), 0o600))
+
+	got, err := readTemplateValues(valuesFile)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"coverage": 87.5, "report": map[string]any{"url": "https://example.com"}}, got)
+
+	invalidFile := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalidFile, []byte(`[1, 2]`), 0o600))
+	_, err = readTemplateValues(invalidFile)
+	require.ErrorContains(t, err, "values_file is not a JSON object")
+}
+
+func TestTitleCase(t *testing.T) {
+	require.Equal(t, "Unit Tests Passed", titleCase("unit tests  passed"))
+	require.Equal(t, "Élan", titleCase("élan"))
+}
@@ -0,0 +1,106 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// maxDescriptionLength is the longest description GitHub accepts on a commit status.
+const maxDescriptionLength = 140
+
+// maxContextLength is the longest context GitHub accepts.
+const maxContextLength = 255
+
+// The values of description_overflow.
+const (
+	descriptionOverflowTruncate = "truncate"
+	descriptionOverflowError    = "error"
+)
+
+// ellipsis marks a truncated description.
+const ellipsis = "…"
+
+// fitDescription fits the description to the length GitHub accepts, either by truncating it with an ellipsis or by
+// failing, depending on the overflow setting.
+func fitDescription(name, description, overflow string) (string, error) {
+	if utf8.RuneCountInString(description) <= maxDescriptionLength {
+		return description, nil
+	}
+	if overflow == descriptionOverflowError {
+		return "", fmt.Errorf("%s is longer than %d characters", name, maxDescriptionLength)
+	}
+	return truncateDescription(description), nil
+}
+
+// fitDescriptions fits the description of the inputs and of every status with fitDescription. Check runs have no
+// limit on the description, it is only used for their output.
+func (in input) fitDescriptions() (input, error) {
+	// Accumulate errors
+	var errs *multierror.Error
+
+	var err error
+	if in.mode != modeCheckRun {
+		in.description, err = fitDescription("description", in.description, in.descriptionOverflow)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+
+	statuses := make([]statusEntry, len(in.statuses))
+	for i, entry := range in.statuses {
+		entry.Description, err = fitDescription(fmt.Sprintf("statuses[%d].description", i), entry.Description, in.descriptionOverflow)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		}
+		statuses[i] = entry
+	}
+	if len(statuses) > 0 {
+		in.statuses = statuses
+	}
+
+	if errs != nil {
+		errs.ErrorFormat = joinErrors
+		return in, errs
+	}
+	return in, nil
+}
+
+// truncateDescription cuts the description at a rune boundary so it fits with an ellipsis at the end.
+func truncateDescription(description string) string {
+	if utf8.RuneCountInString(description) <= maxDescriptionLength {
+		return description
+	}
+	runes := []rune(description)
+	return strings.TrimRightFunc(string(runes[:maxDescriptionLength-1]), unicode.IsSpace) + ellipsis
+}
+
+// validateDetailsURL checks that the details URL is an absolute http or https URL, which GitHub needs to link to it.
+func validateDetailsURL(name, detailsURL string) error {
+	if detailsURL == "" {
+		return nil
+	}
+	u, err := url.Parse(detailsURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s is not an absolute http or https URL: %s", name, detailsURL)
+	}
+	return nil
+}
+
+// validateContext checks that the context fits GitHub's limit and has no control characters such as newlines.
+func validateContext(name, statusContext string) error {
+	if utf8.RuneCountInString(statusContext) > maxContextLength {
+		return fmt.Errorf("%s is longer than %d characters", name, maxContextLength)
+	}
+	if strings.IndexFunc(statusContext, unicode.IsControl) >= 0 {
+		return fmt.Errorf("%s has control characters: %q", name, statusContext)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFitDescription(t *testing.T) {
+	long := strings.Repeat("ü", 150)
+
+	got, err := fitDescription("description", "short", descriptionOverflowTruncate)
+	require.NoError(t, err)
+	require.Equal(t, "short", got)
+
+	got, err = fitDescription("description", long, "")
+	require.NoError(t, err)
+	require.Equal(t, maxDescriptionLength, utf8.RuneCountInString(got))
+	require.Equal(t, strings.Repeat("ü", 139)+ellipsis, got)
+
+	_, err = fitDescription("description", long, descriptionOverflowError)
+	require.EqualError(t, err, "description is longer than 140 characters")
+}
+
+func TestTruncateDescription(t *testing.T) {
+	// Trailing space before the cut is dropped so the ellipsis follows the last word
+	description := strings.Repeat("a", 138) + " bcdef"
+	require.Equal(t, strings.Repeat("a", 138)+ellipsis, truncateDescription(description))
+}
+
+func TestValidateDetailsURL(t *testing.T) {
+	require.NoError(t, validateDetailsURL("details_url", ""))
+	require.NoError(t, validateDetailsURL("details_url", "https://example.com/runs/1"))
+	require.NoError(t, validateDetailsURL("details_url", "http://localhost:8080"))
+	require.EqualError(t, validateDetailsURL("details_url", "example.com/runs/1"), "details_url is not an absolute http or https URL: example.com/runs/1")
+	require.Error(t, validateDetailsURL("details_url", "ftp://example.com"))
+	require.Error(t, validateDetailsURL("details_url", "https://"))
+}
+
+func TestValidateContext(t *testing.T) {
+	require.NoError(t, validateContext("context", "ci/unit tests"))
+	require.EqualError(t, validateContext("context", strings.Repeat("a", 256)), "context is longer than 255 characters")
+	require.EqualError(t, validateContext("context", "unit\ntests"), `context has control characters: "unit\ntests"`)
+}
+
+func TestValidateInputsReportsAllProblems(t *testing.T) {
+	in := input{
+		token:               "foo",
+		description:         strings.Repeat("a", 141),
+		descriptionOverflow: descriptionOverflowError,
+		statuses: []statusEntry{
+			{Context: "unit\t", State: "success", DetailsURL: "some-url"},
+		},
+	}
+	_, err := validateRequiredInputs(in)
+	require.EqualError(t, err, "description is longer than 140 characters, "+
+		"statuses[0].details_url is not an absolute http or https URL: some-url, "+
+		`statuses[0].context has control characters: "unit\t"`)
+}
+
+func TestValidateInputsTruncatesDescriptions(t *testing.T) {
+	in := input{
+		token:       "foo",
+		state:       "success",
+		description: strings.Repeat("a", 141),
+		statuses:    []statusEntry{{Context: "unit", State: "success", Description: strings.Repeat("b", 141)}},
+	}
+	got, err := validateRequiredInputs(in)
+	require.NoError(t, err)
+	require.Equal(t, strings.Repeat("a", 139)+ellipsis, got.description)
+	require.Equal(t, strings.Repeat("b", 139)+ellipsis, got.statuses[0].Description)
+}
@@ -0,0 +1,197 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sethvargo/go-retry"
+)
+
+// The values of the command input. set posts statuses, get and list read them, wait blocks until statuses finish and
+// rollup posts a status that aggregates other statuses.
+const (
+	commandSet    = "set"
+	commandGet    = "get"
+	commandList   = "list"
+	commandWait   = "wait"
+	commandRollup = "rollup"
+)
+
+const waitStatusesErr = "statuses is not supported with command wait"
+const waitTargetsErr = "targets is not supported with command wait"
+const waitModeErr = "mode check-run is not supported with command wait"
+
+// defaultWaitTimeout is how long wait polls when timeout is not set.
+const defaultWaitTimeout = 10 * time.Minute
+
+// defaultPollInterval is the first interval between polls when poll_interval is not set.
+const defaultPollInterval = 5 * time.Second
+
+// maxPollInterval caps the backoff between polls.
+const maxPollInterval = time.Minute
+
+// waitResult is the state of every context that was waited for.
+type waitResult struct {
+	state    string
+	contexts []string
+	states   map[string]string
+}
+
+// waitForStatuses polls the statuses of the commit until every context is finished or the timeout passes. The time
+// between polls grows with a fibonacci backoff. The result has success when every context succeeded, failure when one
+// failed or errored and pending when the timeout passed first.
+func (gh *ghClient) waitForStatuses(ctx context.Context) (waitResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, gh.input.waitTimeoutOrDefault())
+	defer cancel()
+
+	t := gh.input.defaultTarget()
+	backoff := retry.WithCappedDuration(maxPollInterval, retry.NewFibonacci(gh.input.pollIntervalOrDefault()))
+	// Until the statuses are read every context is pending
+	result := evaluateWait(gh.input.contexts, nil)
+	for {
+		statuses, err := gh.combinedStatus(ctx, t)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return result, nil
+		}
+		if err != nil {
+			return waitResult{}, err
+		}
+
+		states := map[string]string{}
+		for statusContext, status := range statuses {
+			states[statusContext] = status.GetState()
+		}
+		result = evaluateWait(gh.input.contexts, states)
+		if result.state != "pending" {
+			return result, nil
+		}
+		action.Infof("Waiting for %s on %s", strings.Join(result.pendingContexts(), ", "), t)
+
+		next, _ := backoff.Next()
+		err = gh.wait(ctx, next)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return result, nil
+		}
+		if err != nil {
+			return waitResult{}, err
+		}
+	}
+}
+
+// evaluateWait works out the overall state of the contexts. When no contexts are listed every context with a status
+// is waited for, and at least one has to exist. Contexts without a status yet are pending.
+func evaluateWait(contexts []string, states map[string]string) waitResult {
+	if len(contexts) == 0 {
+		for statusContext := range states {
+			contexts = append(contexts, statusContext)
+		}
+		sort.Strings(contexts)
+	}
+
+	result := waitResult{state: "success", contexts: contexts, states: map[string]string{}}
+	if len(contexts) == 0 {
+		result.state = "pending"
+	}
+	for _, statusContext := range contexts {
+		state, ok := states[statusContext]
+		if !ok {
+			state = "pending"
+		}
+		result.states[statusContext] = state
+
+		switch {
+		case state == "failure" || state == "error":
+			result.state = "failure"
+		case state == "pending" && result.state == "success":
+			result.state = "pending"
+		}
+	}
+	return result
+}
+
+// pendingContexts returns the contexts that are not finished yet.
+func (r waitResult) pendingContexts() []string {
+	var pending []string
+	for _, statusContext := range r.contexts {
+		if r.states[statusContext] == "pending" {
+			pending = append(pending, statusContext)
+		}
+	}
+	if len(r.contexts) == 0 {
+		pending = append(pending, "the first status")
+	}
+	return pending
+}
+
+// err returns why waiting failed, or nil when every context succeeded.
+func (r waitResult) err(timeout time.Duration) error {
+	switch r.state {
+	case "success":
+		return nil
+	case "pending":
+		return fmt.Errorf("timed out after %s waiting for %s", timeout, strings.Join(r.pendingContexts(), ", "))
+	}
+
+	var failed []string
+	for _, statusContext := range r.contexts {
+		if state := r.states[statusContext]; state == "failure" || state == "error" {
+			failed = append(failed, fmt.Sprintf("%s is %s", statusContext, state))
+		}
+	}
+	return errors.New(strings.Join(failed, ", "))
+}
+
+// outputs returns the overall state and the state of every context as step outputs.
+func (r waitResult) outputs() map[string]string {
+	return map[string]string{
+		"state":    r.state,
+		"statuses": marshalJSON(r.states),
+	}
+}
+
+// summary renders the state of every context as a Markdown table for the job summary.
+func (r waitResult) summary(t target) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Statuses of %s\n\n", t)
+	b.WriteString("| Context | State |\n")
+	b.WriteString("| ------- | ----- |\n")
+	for _, statusContext := range r.contexts {
+		state := r.states[statusContext]
+		fmt.Fprintf(&b, "| %s | %s |\n", escapeTableCell(statusContext), strings.TrimSpace(fmt.Sprintf("%s %s", stateEmoji[state], state)))
+	}
+	return b.String()
+}
+
+// waitTimeoutOrDefault returns how long to wait for the statuses.
+func (in input) waitTimeoutOrDefault() time.Duration {
+	if in.waitTimeout == 0 {
+		return defaultWaitTimeout
+	}
+	return in.waitTimeout
+}
+
+// pollIntervalOrDefault returns the first interval between polls.
+func (in input) pollIntervalOrDefault() time.Duration {
+	if in.pollInterval == 0 {
+		return defaultPollInterval
+	}
+	return in.pollInterval
+}
+
+// parseContexts parses a newline or comma separated list of contexts.
+func parseContexts(contexts string) []string {
+	var parsed []string
+	for _, statusContext := range strings.FieldsFunc(contexts, func(r rune) bool { return r == '\n' || r == ',' }) {
+		if statusContext = strings.TrimSpace(statusContext); statusContext != "" {
+			parsed = append(parsed, statusContext)
+		}
+	}
+	return parsed
+}
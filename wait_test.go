@@ -0,0 +1,153 @@
+// Copyright (c) Curt Bushko.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v53/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateWait(t *testing.T) {
+	cases := []struct {
+		name     string
+		contexts []string
+		states   map[string]string
+		expected string
+	}{
+		{
+			name:     "all_success",
+			states:   map[string]string{"unit": "success", "lint": "success"},
+			expected: "success",
+		},
+		{
+			name:     "pending",
+			states:   map[string]string{"unit": "success", "lint": "pending"},
+			expected: "pending",
+		},
+		{
+			name:     "failure_wins_over_pending",
+			states:   map[string]string{"unit": "pending", "lint": "error"},
+			expected: "failure",
+		},
+		{
+			name:     "no_statuses_yet",
+			states:   map[string]string{},
+			expected: "pending",
+		},
+		{
+			name:     "listed_context_missing",
+			contexts: []string{"unit", "e2e"},
+			states:   map[string]string{"unit": "success", "lint": "failure"},
+			expected: "pending",
+		},
+		{
+			name:     "listed_contexts_only",
+			contexts: []string{"unit"},
+			states:   map[string]string{"unit": "success", "lint": "failure"},
+			expected: "success",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.expected, evaluateWait(c.contexts, c.states).state)
+		})
+	}
+}
+
+func TestWaitResultErr(t *testing.T) {
+	result := evaluateWait(nil, map[string]string{"unit": "success", "lint": "failure", "e2e": "error"})
+	require.EqualError(t, result.err(time.Minute), "e2e is error, lint is failure")
+
+	result = evaluateWait([]string{"unit", "e2e"}, map[string]string{"unit": "success"})
+	require.EqualError(t, result.err(time.Minute), "timed out after 1m0s waiting for e2e")
+
+	result = evaluateWait(nil, nil)
+	require.EqualError(t, result.err(time.Minute), "timed out after 1m0s waiting for the first status")
+
+	result = evaluateWait(nil, map[string]string{"unit": "success"})
+	require.NoError(t, result.err(time.Minute))
+	require.Equal(t, map[string]string{"state": "success", "statuses": `{"unit":"success"}`}, result.outputs())
+}
+
+func TestWaitForStatuses(t *testing.T) {
+	commits := &mockghSequenceClient{responses: [][]*github.RepoStatus{
+		{newRepoStatus("unit", "pending")},
+		{newRepoStatus("unit", "pending"), newRepoStatus("lint", "success")},
+		{newRepoStatus("unit", "success"), newRepoStatus("lint", "success")},
+	}}
+	var waits []time.Duration
+	gh := ghClient{
+		commits: commits,
+		sleep: func(_ context.Context, d time.Duration) error {
+			waits = append(waits, d)
+			return nil
+		},
+		input: input{
+			owner:        "some-owner",
+			repository:   "some-repo",
+			sha:          "some-sha",
+			contexts:     []string{"unit", "lint"},
+			pollInterval: time.Second,
+		},
+	}
+
+	result, err := gh.waitForStatuses(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "success", result.state)
+	require.Equal(t, []time.Duration{time.Second, 2 * time.Second}, waits)
+}
+
+func TestWaitForStatusesTimeout(t *testing.T) {
+	commits := &mockghSequenceClient{responses: [][]*github.RepoStatus{{newRepoStatus("unit", "pending")}}}
+	gh := ghClient{
+		commits: commits,
+		sleep: func(ctx context.Context, _ time.Duration) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		input: input{
+			owner:       "some-owner",
+			repository:  "some-repo",
+			sha:         "some-sha",
+			waitTimeout: 10 * time.Millisecond,
+		},
+	}
+
+	result, err := gh.waitForStatuses(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "pending", result.state)
+	require.EqualError(t, result.err(gh.input.waitTimeout), "timed out after 10ms waiting for unit")
+}
+
+func TestParseContexts(t *testing.T) {
+	require.Equal(t, []string{"ci/unit", "ci/lint", "e2e"}, parseContexts("ci/unit, ci/lint\n\ne2e\n"))
+	require.Nil(t, parseContexts(""))
+}
+
+func newRepoStatus(statusContext, state string) *github.RepoStatus {
+	return &github.RepoStatus{Context: github.String(statusContext), State: github.String(state)}
+}
+
+// mockghSequenceClient returns the next combined status on every call and keeps returning the last one.
+type mockghSequenceClient struct {
+	responses [][]*github.RepoStatus
+	calls     int
+}
+
+func (m *mockghSequenceClient) GetCommitSHA1(_ context.Context, _, _, ref, _ string) (string, *github.Response, error) {
+	return ref, nil, nil
+}
+
+func (m *mockghSequenceClient) GetCombinedStatus(_ context.Context, _, _, _ string, _ *github.ListOptions) (*github.CombinedStatus, *github.Response, error) {
+	i := m.calls
+	if i >= len(m.responses) {
+		i = len(m.responses) - 1
+	}
+	m.calls++
+	return &github.CombinedStatus{Statuses: m.responses[i]}, &github.Response{}, nil
+}